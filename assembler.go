@@ -1,5 +1,148 @@
 package kman
 
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
 type Assembler interface {
 	Assemble() ([]Item, error)
 }
+
+// sourceExtensions lists the file extensions read by each assembler type.
+var sourceExtensions = map[string][]string{
+	AssemblerTypeGo:       []string{".go"},
+	AssemblerTypeMarkdown: []string{".md", ".markdown"},
+}
+
+func hasSourceExtension(assemblerType, path string) bool {
+
+	for _, ext := range sourceExtensions[assemblerType] {
+		if filepath.Ext(path) == ext {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AssemblerOptions restricts the part of a filesystem an assembler reads.
+// The zero value walks the whole filesystem from its working directory.
+type AssemblerOptions struct {
+	Root    string
+	Exclude []string
+	Cache   Cache
+
+	// Variables are what the content of items may refer to, or nil to leave
+	// references alone.
+	Variables *Variables
+}
+
+// itemCacheVersion is part of every item cache key; bump it whenever the
+// items found in a file can change for the same file contents.
+const itemCacheVersion = "3"
+
+func (o AssemblerOptions) root() string {
+
+	if o.Root == "" {
+		return "."
+	}
+
+	return o.Root
+}
+
+// Excludes reports whether a path matches one of the exclude patterns, either
+// as a whole (relative to the root) or by its base name.
+func (o AssemblerOptions) Excludes(path string) bool {
+
+	rel, err := filepath.Rel(o.root(), path)
+
+	if err != nil {
+		rel = path
+	}
+
+	for _, pattern := range o.Exclude {
+
+		pattern = filepath.Clean(pattern)
+
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findFiles walks the filesystem and returns every non-empty file accepted by
+// match, skipping excluded files and directories.
+func (o AssemblerOptions) findFiles(fs afero.Fs, match func(path string) bool) (files []string) {
+
+	root := o.root()
+
+	afero.Walk(fs, root, func(path string, info os.FileInfo, err error) error {
+
+		if err != nil {
+			return nil
+		}
+
+		if path != root && o.Excludes(path) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !info.IsDir() && info.Size() > 0 && match(path) {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	return
+}
+
+// cachedItems returns the items found earlier in a file with the same
+// contents, or calls itemise and caches its result.
+func (o AssemblerOptions) cachedItems(assemblerType, path string, contents []byte, itemise func() ([]Item, error)) ([]Item, error) {
+
+	if o.Cache == nil {
+		return itemise()
+	}
+
+	key := contentKey("items", []byte(itemCacheVersion), []byte(assemblerType), []byte(path), contents)
+
+	var cached []cachedItem
+
+	if o.Cache.Get(key, &cached) {
+
+		items := make([]Item, len(cached))
+
+		for i, c := range cached {
+			items[i] = c.item()
+		}
+
+		return items, nil
+	}
+
+	items, err := itemise()
+
+	if err != nil {
+		return items, err
+	}
+
+	cached = make([]cachedItem, len(items))
+
+	for i, item := range items {
+		cached[i] = cachedItem{Item: item, Lines: item.lines}
+	}
+
+	return items, o.Cache.Set(key, cached)
+}
@@ -5,8 +5,6 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
-	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/spf13/afero"
@@ -18,12 +16,18 @@ const (
 )
 
 type assemblerGoFilesystem struct {
-	fs afero.Fs
+	fs      afero.Fs
+	options AssemblerOptions
 }
 
 func NewGoAssemblerWithFilesystem(fs afero.Fs) Assembler {
+	return NewGoAssemblerWithOptions(fs, AssemblerOptions{})
+}
+
+func NewGoAssemblerWithOptions(fs afero.Fs, options AssemblerOptions) Assembler {
 	return &assemblerGoFilesystem{
-		fs: fs,
+		fs:      fs,
+		options: options,
 	}
 }
 
@@ -34,45 +38,78 @@ func NewGoAssemblerFromLocalFilesystem() Assembler {
 func (g *assemblerGoFilesystem) Assemble() ([]Item, error) {
 	docItems := []Item{}
 
-	astFiles, err := g.parseFiles(g.findGoFiles())
+	files := g.findGoFiles()
+	fileItems := make([][]Item, len(files))
 
-	if err != nil {
-		return docItems, err
-	}
+	err := parallel(len(files), func(i int) error {
+
+		contents, err := afero.ReadFile(g.fs, files[i])
 
-	for path, f := range astFiles {
-		for _, d := range f.Comments {
-			if err := g.findCommentReference(path, d, &docItems); err != nil {
-				return docItems, nil
-			}
+		if err != nil {
+			return err
 		}
 
-		for _, d := range f.Decls {
-			g.findReference(path, topicRef, d.(ast.Node), &docItems, ItemTypeTopic)
-			g.findReference(path, termRef, d.(ast.Node), &docItems, ItemTypeTerm)
+		fileItems[i], err = g.options.cachedItems(AssemblerTypeGo, files[i], contents, func() ([]Item, error) {
+			return g.assembleFile(files[i], contents)
+		})
+
+		if err != nil {
+			return err
 		}
+
+		if err := g.options.expandVariables(fileItems[i]); err != nil {
+			return err
+		}
+
+		return g.options.includeSnippets(g.fs, fileItems[i])
+	})
+
+	if err != nil {
+		return docItems, err
+	}
+
+	for _, items := range fileItems {
+		docItems = append(docItems, items...)
 	}
 
 	return docItems, nil
 }
 
-func (g *assemblerGoFilesystem) findGoFiles() (files []string) {
+// assembleFile finds the items in the contents of a file, as read for its
+// cache key, so that they can't be from a later version of the file.
+func (g *assemblerGoFilesystem) assembleFile(path string, contents []byte) ([]Item, error) {
+	docItems := []Item{}
 
-	afero.Walk(g.fs, ".", func(path string, info os.FileInfo, err error) error {
+	fileSet := token.NewFileSet()
+	f, err := parser.ParseFile(fileSet, path, contents, parser.ParseComments)
 
-		if !info.IsDir() && info.Size() > 0 && filepath.Ext(path) == ".go" {
-			files = append(files, path)
+	if err != nil {
+		return docItems, err
+	}
+
+	for _, d := range f.Comments {
+		if err := g.findCommentReference(fileSet, path, d, &docItems); err != nil {
+			return docItems, nil
 		}
+	}
 
-		return nil
-	})
+	for _, d := range f.Decls {
+		g.findReference(fileSet, path, topicRef, d.(ast.Node), &docItems, ItemTypeTopic)
+		g.findReference(fileSet, path, termRef, d.(ast.Node), &docItems, ItemTypeTerm)
+	}
 
-	return
+	return docItems, nil
 }
 
-func (g *assemblerGoFilesystem) parseFiles(paths []string) (map[string]*ast.File, error) {
+func (g *assemblerGoFilesystem) findGoFiles() (files []string) {
+
+	return g.options.findFiles(g.fs, func(path string) bool {
+		return hasSourceExtension(AssemblerTypeGo, path)
+	})
+}
+
+func (g *assemblerGoFilesystem) parseFiles(fileSet *token.FileSet, paths []string) (map[string]*ast.File, error) {
 
-	fileSet := token.NewFileSet()
 	astFiles := make(map[string]*ast.File)
 
 	for _, path := range paths {
@@ -95,18 +132,20 @@ func (g *assemblerGoFilesystem) parseFiles(paths []string) (map[string]*ast.File
 	return astFiles, nil
 }
 
-func (g *assemblerGoFilesystem) findReference(path, symbol string, n ast.Node, items *[]Item, itemType ItemType) {
+func (g *assemblerGoFilesystem) findReference(fileSet *token.FileSet, path, symbol string, n ast.Node, items *[]Item, itemType ItemType) {
 
 	switch x := n.(type) {
 	case *ast.GenDecl:
 		switch x.Tok {
 		case token.VAR:
-			g.findVarReference(path, symbol, x, items, itemType)
+			g.findVarReference(fileSet, path, symbol, x, items, itemType)
 		}
 	}
 }
 
-func (g *assemblerGoFilesystem) findCommentReference(path string, x *ast.CommentGroup, items *[]Item) error {
+// findCommentReference itemises every comment of a group, moving the lines
+// of its items to where the comment is in the file.
+func (g *assemblerGoFilesystem) findCommentReference(fileSet *token.FileSet, path string, x *ast.CommentGroup, items *[]Item) error {
 
 	if x == nil {
 		return nil
@@ -114,15 +153,19 @@ func (g *assemblerGoFilesystem) findCommentReference(path string, x *ast.Comment
 
 	for _, com := range x.List {
 
+		found := len(*items)
+
 		if err := NewItemiserFromString(path, strings.TrimSuffix(com.Text, "*/")).Itemise(items); err != nil {
 			return err
 		}
+
+		offsetLines((*items)[found:], uint(fileSet.Position(com.Pos()).Line))
 	}
 
 	return nil
 }
 
-func (g *assemblerGoFilesystem) findVarReference(path, symbol string, x *ast.GenDecl, items *[]Item, itemType ItemType) {
+func (g *assemblerGoFilesystem) findVarReference(fileSet *token.FileSet, path, symbol string, x *ast.GenDecl, items *[]Item, itemType ItemType) {
 
 	if len(x.Specs) == 0 {
 		return
@@ -177,6 +220,7 @@ func (g *assemblerGoFilesystem) findVarReference(path, symbol string, x *ast.Gen
 		*items = append(*items, Item{
 			Type:     itemType,
 			FileName: path,
+			Line:     uint(fileSet.Position(x.Pos()).Line),
 			Title:    ref,
 			Handle:   name,
 			Content:  comment,
@@ -2,6 +2,8 @@ package kman
 
 import (
 	"fmt"
+	"go/token"
+	"sync"
 	"testing"
 
 	"github.com/endiangroup/snaptest"
@@ -113,7 +115,7 @@ func Test_AValidGoFileSystemAssemblerShouldbeAbleToParseGivenFiles(t *testing.T)
 				fs: test.input.fs,
 			}
 
-			astFiles, err := generator.parseFiles(test.input.files)
+			astFiles, err := generator.parseFiles(token.NewFileSet(), test.input.files)
 
 			if !test.output.err {
 				require.Nil(t, err)
@@ -243,3 +245,33 @@ var 323_ = kman.Topic("Root")
 		})
 	}
 }
+
+// openCountingFs counts the times each file is opened.
+type openCountingFs struct {
+	afero.Fs
+
+	mu    sync.Mutex
+	opens map[string]int
+}
+
+func (fs *openCountingFs) Open(name string) (afero.File, error) {
+
+	fs.mu.Lock()
+	fs.opens[name]++
+	fs.mu.Unlock()
+
+	return fs.Fs.Open(name)
+}
+
+func Test_AGoFileSystemAssemblerShouldReadEachFileOnce(t *testing.T) {
+
+	fs := &openCountingFs{
+		Fs:    newMockFilesystem(t, map[string]string{"a.go": "package a\n\n/*\nTopic: A\nBody\n*/\nfunc A() {}\n"}),
+		opens: make(map[string]int),
+	}
+
+	items, err := NewGoAssemblerWithOptions(fs, AssemblerOptions{Cache: NewFilesystemCache(afero.NewMemMapFs(), "cache")}).Assemble()
+	require.Nil(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, 1, fs.opens["a.go"])
+}
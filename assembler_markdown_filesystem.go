@@ -1,19 +1,20 @@
 package kman
 
-import (
-	"os"
-	"path/filepath"
-
-	"github.com/spf13/afero"
-)
+import "github.com/spf13/afero"
 
 type assemblerMarkdownFilesystem struct {
-	fs afero.Fs
+	fs      afero.Fs
+	options AssemblerOptions
 }
 
 func NewMarkdownAssemblerWithFilesystem(fs afero.Fs) Assembler {
+	return NewMarkdownAssemblerWithOptions(fs, AssemblerOptions{})
+}
+
+func NewMarkdownAssemblerWithOptions(fs afero.Fs, options AssemblerOptions) Assembler {
 	return &assemblerMarkdownFilesystem{
-		fs: fs,
+		fs:      fs,
+		options: options,
 	}
 }
 
@@ -26,18 +27,38 @@ func (m *assemblerMarkdownFilesystem) Assemble() ([]Item, error) {
 	docItems := []Item{}
 
 	files := m.findMarkdownFiles()
+	fileItems := make([][]Item, len(files))
+
+	err := parallel(len(files), func(i int) error {
 
-	for _, file := range files {
+		content, err := afero.ReadFile(m.fs, files[i])
 
-		content, err := afero.ReadFile(m.fs, file)
+		if err != nil {
+			return err
+		}
+
+		fileItems[i], err = m.options.cachedItems(AssemblerTypeMarkdown, files[i], content, func() (items []Item, err error) {
+			err = NewItemiserFromString(files[i], string(content)).Itemise(&items)
+			return
+		})
 
 		if err != nil {
-			return docItems, err
+			return err
 		}
 
-		if err := NewItemiserFromString(file, string(content)).Itemise(&docItems); err != nil {
-			return docItems, err
+		if err := m.options.expandVariables(fileItems[i]); err != nil {
+			return err
 		}
+
+		return m.options.includeSnippets(m.fs, fileItems[i])
+	})
+
+	if err != nil {
+		return docItems, err
+	}
+
+	for _, items := range fileItems {
+		docItems = append(docItems, items...)
 	}
 
 	return docItems, nil
@@ -45,14 +66,7 @@ func (m *assemblerMarkdownFilesystem) Assemble() ([]Item, error) {
 
 func (m *assemblerMarkdownFilesystem) findMarkdownFiles() (files []string) {
 
-	afero.Walk(m.fs, ".", func(path string, info os.FileInfo, err error) error {
-
-		if !info.IsDir() && info.Size() > 0 && (filepath.Ext(path) == ".md" || filepath.Ext(path) == ".markdown") {
-			files = append(files, path)
-		}
-
-		return nil
+	return m.options.findFiles(m.fs, func(path string) bool {
+		return hasSourceExtension(AssemblerTypeMarkdown, path)
 	})
-
-	return
 }
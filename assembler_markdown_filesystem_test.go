@@ -117,3 +117,28 @@ Line 2
 		})
 	}
 }
+
+func Test_AMarkdownFileSystemAssemblerShouldHonourRootAndExcludes(t *testing.T) {
+
+	fs := newMockFilesystem(t, map[string]string{
+		"outside.md":              `hello`,
+		"docs/valid.md":           `hello`,
+		"docs/notes.draft.md":     `hello`,
+		"docs/public/index.md":    `hello`,
+		"docs/nested/public.md":   `hello`,
+		"docs/nested/deep/one.md": `hello`,
+	})
+
+	assembler := &assemblerMarkdownFilesystem{
+		fs: fs,
+		options: AssemblerOptions{
+			Root:    "docs",
+			Exclude: []string{"public", "*.draft.md", "nested/deep"},
+		},
+	}
+
+	require.Equal(t, []string{
+		"docs/nested/public.md",
+		"docs/valid.md",
+	}, assembler.findMarkdownFiles())
+}
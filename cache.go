@@ -0,0 +1,8 @@
+package kman
+
+// Cache stores intermediate build results between runs, so that unchanged
+// inputs need not be processed again. Values are encoded as JSON.
+type Cache interface {
+	Get(key string, value interface{}) bool
+	Set(key string, value interface{}) error
+}
@@ -0,0 +1,77 @@
+package kman
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+type cacheFilesystem struct {
+	fs  afero.Fs
+	dir string
+}
+
+func NewFilesystemCache(fs afero.Fs, dir string) Cache {
+	return &cacheFilesystem{
+		fs:  fs,
+		dir: dir,
+	}
+}
+
+func NewCacheFromLocalFilesystem(dir string) Cache {
+	return NewFilesystemCache(afero.NewOsFs(), dir)
+}
+
+func (c *cacheFilesystem) path(key string) string {
+
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+
+	return filepath.Join(c.dir, name[:2], name+".json")
+}
+
+func (c *cacheFilesystem) Get(key string, value interface{}) bool {
+
+	data, err := afero.ReadFile(c.fs, c.path(key))
+
+	if err != nil {
+		return false
+	}
+
+	return json.Unmarshal(data, value) == nil
+}
+
+func (c *cacheFilesystem) Set(key string, value interface{}) error {
+
+	data, err := json.Marshal(value)
+
+	if err != nil {
+		return err
+	}
+
+	path := c.path(key)
+
+	if err := c.fs.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	return afero.WriteFile(c.fs, path, data, 0644)
+}
+
+// contentKey builds a cache key from a kind of result and the inputs it
+// was computed from.
+func contentKey(kind string, inputs ...[]byte) string {
+
+	hash := sha256.New()
+
+	for _, input := range inputs {
+		sum := sha256.Sum256(input)
+		hash.Write(sum[:])
+	}
+
+	return kind + ":" + hex.EncodeToString(hash.Sum(nil))
+}
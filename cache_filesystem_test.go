@@ -0,0 +1,60 @@
+package kman
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AFilesystemCacheShouldStoreAndRetrieveValues(t *testing.T) {
+
+	cache := NewFilesystemCache(afero.NewMemMapFs(), ".kman/cache")
+
+	var items []Item
+	require.False(t, cache.Get("missing", &items))
+
+	stored := []Item{
+		Item{Type: ItemTypeTerm, FileName: "a.md", Title: "A", Handle: "a", Content: "Hello"},
+	}
+
+	require.Nil(t, cache.Set("key", stored))
+	require.True(t, cache.Get("key", &items))
+	require.Equal(t, stored, items)
+}
+
+func Test_AContentKeyShouldDependOnEveryInput(t *testing.T) {
+
+	require.Equal(t, contentKey("a", []byte("b"), []byte("c")), contentKey("a", []byte("b"), []byte("c")))
+	require.NotEqual(t, contentKey("a", []byte("b"), []byte("c")), contentKey("a", []byte("bc")))
+	require.NotEqual(t, contentKey("a", []byte("b")), contentKey("x", []byte("b")))
+}
+
+func Test_AnAssemblerShouldReuseCachedItems(t *testing.T) {
+
+	fs := newMockFilesystem(t, map[string]string{
+		"doc/topics.md": "Topic: A\nHello",
+	})
+
+	cache := NewFilesystemCache(afero.NewMemMapFs(), ".kman/cache")
+	assembler := NewMarkdownAssemblerWithOptions(fs, AssemblerOptions{Cache: cache})
+
+	first, err := assembler.Assemble()
+	require.Nil(t, err)
+
+	key := contentKey("items", []byte(itemCacheVersion), []byte(AssemblerTypeMarkdown), []byte("doc/topics.md"), []byte("Topic: A\nHello"))
+
+	var cached []cachedItem
+	require.True(t, cache.Get(key, &cached))
+	require.Len(t, cached, 1)
+	require.Equal(t, first[0], cached[0].item())
+	require.Equal(t, []uint{2}, cached[0].Lines)
+
+	// A cached result is used instead of parsing the file again.
+	cached[0].Title = "From cache"
+	require.Nil(t, cache.Set(key, cached))
+
+	second, err := assembler.Assemble()
+	require.Nil(t, err)
+	require.Equal(t, "From cache", second[0].Title)
+}
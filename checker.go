@@ -0,0 +1,22 @@
+package kman
+
+import "fmt"
+
+type Checker interface {
+	Check(Documentation) []Problem
+}
+
+type Problem struct {
+	FileName string
+	Line     uint
+	Message  string
+}
+
+func (p Problem) String() string {
+
+	if p.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", p.FileName, p.Line, p.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", p.FileName, p.Message)
+}
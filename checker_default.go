@@ -0,0 +1,70 @@
+package kman
+
+import (
+	"fmt"
+	"path"
+)
+
+type checkerDefault struct{}
+
+func NewDefaultChecker() Checker {
+	return &checkerDefault{}
+}
+
+func (c *checkerDefault) Check(d Documentation) (problems []Problem) {
+
+	if d.RootTopic.Title == "" {
+		problems = append(problems, Problem{Message: "no root topic found"})
+	}
+
+	c.checkTopic("/", d.RootTopic, &problems)
+
+	handles := map[string]Item{}
+
+	for _, term := range d.Glossary {
+
+		if previous, ok := handles[term.Handle]; ok {
+			problems = append(problems, c.problem(term.Item, "term handle %q already used in %s", term.Handle, previous.FileName))
+		} else {
+			handles[term.Handle] = term.Item
+		}
+
+		if term.Content == "" {
+			problems = append(problems, c.problem(term.Item, "term %q has no definition", term.Title))
+		}
+	}
+
+	return
+}
+
+func (c *checkerDefault) checkTopic(url string, topic TopicRef, problems *[]Problem) {
+
+	if topic.Title != "" && topic.Content == "" {
+		*problems = append(*problems, c.problem(topic.Item, "topic %q has no content", topic.Title))
+	}
+
+	handles := map[string]Item{}
+
+	for _, child := range topic.Children {
+
+		if child.Handle == "" {
+			*problems = append(*problems, c.problem(child.Item, "topic %q has an empty handle", child.Title))
+		}
+
+		if previous, ok := handles[child.Handle]; ok {
+			*problems = append(*problems, c.problem(child.Item, "topic %q is already defined in %s", path.Join(url, child.Handle), previous.FileName))
+		} else {
+			handles[child.Handle] = child.Item
+		}
+
+		c.checkTopic(path.Join(url, child.Handle), child, problems)
+	}
+}
+
+func (c *checkerDefault) problem(item Item, format string, args ...interface{}) Problem {
+	return Problem{
+		FileName: item.FileName,
+		Line:     item.Line,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
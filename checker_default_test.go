@@ -0,0 +1,61 @@
+package kman
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/endiangroup/snaptest"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ADefaultCheckerShouldFindNoProblemsInValidDocumentation(t *testing.T) {
+
+	checker := NewDefaultChecker()
+
+	require.Empty(t, checker.Check(newValidDocumentation(t)))
+}
+
+func Test_ADefaultCheckerShouldReportProblems(t *testing.T) {
+
+	for cycle, test := range []struct {
+		description string
+
+		input Documentation
+	}{
+		{
+			description: "No root topic",
+			input:       Documentation{},
+		},
+		{
+			description: "Duplicate topic handles",
+			input: Documentation{
+				RootTopic: TopicRef{
+					Item: Item{Title: "Root", Content: "Root", FileName: "a.md"},
+					Children: []TopicRef{
+						TopicRef{Item: Item{Title: "A", Handle: "a", Content: "A", FileName: "a.md"}},
+						TopicRef{Item: Item{Title: "Another A", Handle: "a", Content: "A", FileName: "b.md", Line: 3}},
+					},
+				},
+			},
+		},
+		{
+			description: "Empty content and duplicate terms",
+			input: Documentation{
+				RootTopic: TopicRef{
+					Item: Item{Title: "Root", FileName: "a.md"},
+				},
+				Glossary: []TermRef{
+					TermRef{Item: Item{Title: "A", Handle: "a", Content: "A", FileName: "a.md"}},
+					TermRef{Item: Item{Title: "A", Handle: "a", FileName: "b.md"}},
+				},
+			},
+		},
+	} {
+		t.Run(fmt.Sprintf("Cycle %d: %s", cycle, test.description), func(t *testing.T) {
+
+			checker := NewDefaultChecker()
+
+			snaptest.Snapshot(t, checker.Check(test.input))
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"log"
+)
+
+func buildCommand(flags *flag.FlagSet) func() error {
+
+	project := projectFlags(flags)
+
+	return func() error {
+
+		config, err := project.config()
+
+		if err != nil {
+			return err
+		}
+
+		if err := build(config); err != nil {
+			return err
+		}
+
+		log.Printf("Documentation written to %s\n", config.Output)
+
+		return nil
+	}
+}
+
+func legacyCommand(flags *flag.FlagSet) func() error {
+
+	project := projectFlags(flags)
+	httpAddress := flags.String("http", "", "Serve http on a given address (for example, :8080)")
+
+	return func() error {
+
+		if *httpAddress != "" {
+			return serve(project, *httpAddress, true)
+		}
+
+		config, err := project.config()
+
+		if err != nil {
+			return err
+		}
+
+		return build(config)
+	}
+}
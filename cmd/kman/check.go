@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/kowala-tech/kman"
+)
+
+func checkCommand(flags *flag.FlagSet) func() error {
+
+	project := projectFlags(flags)
+
+	return func() error {
+
+		config, err := project.config()
+
+		if err != nil {
+			return err
+		}
+
+		doc, err := document(config)
+
+		if err != nil {
+			return err
+		}
+
+		problems := kman.NewDefaultChecker().Check(doc)
+
+		for _, p := range problems {
+			log.Println(p)
+		}
+
+		if len(problems) > 0 {
+			return fmt.Errorf("%d problem(s) found", len(problems))
+		}
+
+		log.Printf("%d topic(s) and %d term(s) found, no problems\n", countTopics(doc.RootTopic), len(doc.Glossary))
+
+		return nil
+	}
+}
+
+func countTopics(topic kman.TopicRef) (count int) {
+
+	if topic.Title != "" {
+		count++
+	}
+
+	for _, child := range topic.Children {
+		count += countTopics(child)
+	}
+
+	return
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/kowala-tech/kman"
+)
+
+func exportCommand(flags *flag.FlagSet) func() error {
+
+	project := projectFlags(flags)
+	outputFile := flags.String("o", "", "File to write to (default stdout)")
+
+	return func() error {
+
+		config, err := project.config()
+
+		if err != nil {
+			return err
+		}
+
+		doc, err := document(config)
+
+		if err != nil {
+			return err
+		}
+
+		var w io.Writer = os.Stdout
+
+		if *outputFile != "" {
+
+			f, err := os.Create(*outputFile)
+
+			if err != nil {
+				return err
+			}
+
+			defer f.Close()
+
+			w = f
+		}
+
+		return export(w, doc)
+	}
+}
+
+func export(w io.Writer, doc kman.Documentation) error {
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(doc)
+}
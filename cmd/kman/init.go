@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/kowala-tech/kman"
+	"github.com/spf13/afero"
+)
+
+func initCommand(flags *flag.FlagSet) func() error {
+
+	path := flags.String("config", "kman.yaml", "Config file to write (.yaml, .yml or .toml)")
+	force := flags.Bool("force", false, "Overwrite an existing config file")
+
+	return func() error {
+
+		fs := afero.NewOsFs()
+
+		if exists, _ := afero.Exists(fs, *path); exists && !*force {
+			return fmt.Errorf("%s already exists, use -force to overwrite it", *path)
+		}
+
+		data, err := kman.DefaultConfig().Marshal(*path)
+
+		if err != nil {
+			return err
+		}
+
+		if err := afero.WriteFile(fs, *path, data, 0644); err != nil {
+			return err
+		}
+
+		log.Printf("Config written to %s\n", *path)
+
+		return nil
+	}
+}
@@ -4,76 +4,74 @@ import (
 	"flag"
 	"fmt"
 	"log"
-	"net/http"
-
-	"github.com/kowala-tech/kman"
-	"github.com/spf13/afero"
+	"os"
+	"strings"
 )
 
-var (
-	parseGo      = flag.Bool("go", false, "Parse Go files")
-	parseMd      = flag.Bool("md", true, "Parse Markdown files")
-	templatePath = flag.String("theme", "themes/kman", "Theme path")
-	outputPath   = flag.String("output", "public", "Public assets output path")
-	httpAddress  = flag.String("http", "", "Serve http on a given address (for example, :8080)")
-)
+type command struct {
+	name        string
+	description string
+	flags       func(*flag.FlagSet) func() error
+}
+
+var commands = []command{
+	{"build", "Parse the sources and render the documentation", buildCommand},
+	{"serve", "Build and serve the documentation over http", serveCommand},
+	{"check", "Parse the sources and report problems without rendering", checkCommand},
+	{"test", "Run the examples marked as runnable in topics and terms", testCommand},
+	{"show", "Print the topic tree and glossary", showCommand},
+	{"init", "Write a project config file", initCommand},
+	{"export", "Write the parsed documentation as JSON", exportCommand},
+	{"theme", "Manage themes: 'kman theme extract' copies out the built-in theme", themeCommand},
+}
 
 func main() {
 
-	flag.Parse()
+	args := os.Args[1:]
 
-	if err := build(); err != nil {
-		log.Fatal(err)
+	// Without a subcommand, behave like earlier releases: build, and serve
+	// if an http address is given.
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		run(command{"", "", legacyCommand}, args)
+		return
 	}
 
-	if *httpAddress != "" {
-
-		server := http.FileServer(http.Dir(*outputPath))
-
-		http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-
-			if err := build(); err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				w.Write([]byte(err.Error()))
-			} else {
-				server.ServeHTTP(w, r)
-			}
-		})
+	if args[0] == "help" {
+		usage()
+		return
+	}
 
-		log.Printf("Serving documentation on %s\n", *httpAddress)
-		log.Fatal(http.ListenAndServe(*httpAddress, nil))
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			run(cmd, args[1:])
+			return
+		}
 	}
+
+	fmt.Fprintf(os.Stderr, "kman: unknown command %q\n\n", args[0])
+	usage()
+	os.Exit(2)
 }
 
-func build() error {
+func run(cmd command, args []string) {
 
-	var assemblers []kman.Assembler
+	flags := flag.NewFlagSet(strings.TrimSpace("kman "+cmd.name), flag.ExitOnError)
+	exec := cmd.flags(flags)
 
-	if *parseGo {
-		assemblers = append(assemblers, kman.NewGoAssemblerFromLocalFilesystem())
-	}
+	flags.Parse(args)
 
-	if *parseMd {
-		assemblers = append(assemblers, kman.NewMarkdownAssemblerFromLocalFilesystem())
+	if err := exec(); err != nil {
+		log.Fatal(err)
 	}
+}
 
-	docker := kman.NewDefaultDocumenter(kman.NewDefaultSorter(), assemblers...)
-
-	doc, err := docker.Document()
-
-	if err != nil {
-		return fmt.Errorf("Error 01: %s", err)
-	}
+func usage() {
 
-	renderer := kman.NewRendererAce(
-		afero.NewOsFs(),
-		*templatePath,
-		*outputPath,
-	)
+	fmt.Fprintf(os.Stderr, "Usage: kman <command> [flags]\n\nCommands:\n")
 
-	if err := renderer.Render(doc); err != nil {
-		return fmt.Errorf("Error 02: %s", err)
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.description)
 	}
 
-	return nil
+	fmt.Fprintf(os.Stderr, "\nRun 'kman <command> -h' for the flags of a command.\n")
 }
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/kowala-tech/kman"
+	"github.com/spf13/afero"
+)
+
+// project holds the flags shared by every command that reads the sources.
+// Flags given explicitly override the project config file.
+type project struct {
+	flags      *flag.FlagSet
+	configPath *string
+	parseGo    *bool
+	parseMd    *bool
+	theme      *string
+	output     *string
+	useCache   *bool
+	version    *string
+	vars       variableFlags
+	audience   *string
+	noDrafts   *bool
+}
+
+// variableFlags collects -var name=value flags.
+type variableFlags map[string]string
+
+func (v variableFlags) String() string {
+	return ""
+}
+
+func (v variableFlags) Set(value string) error {
+
+	i := strings.IndexByte(value, '=')
+
+	if i <= 0 {
+		return fmt.Errorf("%q is not name=value", value)
+	}
+
+	v[value[:i]] = value[i+1:]
+
+	return nil
+}
+
+func projectFlags(flags *flag.FlagSet) *project {
+
+	p := &project{
+		flags:      flags,
+		configPath: flags.String("config", "", "Project config file (default kman.yaml, kman.yml or kman.toml if present)"),
+		parseGo:    flags.Bool("go", false, "Parse Go files"),
+		parseMd:    flags.Bool("md", true, "Parse Markdown files"),
+		theme:      flags.String("theme", "", "Theme directory or archive (default the built-in theme)"),
+		output:     flags.String("output", "public", "Public assets output path"),
+		useCache:   flags.Bool("cache", true, "Reuse unchanged results from previous builds"),
+		version:    flags.String("version", "", "Version topics get as {{ .Version }}, or git for the latest tag"),
+		vars:       variableFlags{},
+		audience:   flags.String("audience", "", "Only keep the items for these audiences, separated by commas"),
+		noDrafts:   flags.Bool("exclude-drafts", false, "Leave out draft items"),
+	}
+
+	flags.Var(p.vars, "var", "Variable topics get as {{ .Vars.name }}, as name=value; may be repeated")
+
+	return p
+}
+
+// path returns the config file in use, if any.
+func (p *project) path() string {
+
+	if *p.configPath != "" {
+		return *p.configPath
+	}
+
+	path, _ := kman.FindConfig(afero.NewOsFs())
+
+	return path
+}
+
+func (p *project) config() (kman.Config, error) {
+
+	fs := afero.NewOsFs()
+	config := kman.DefaultConfig()
+
+	if path := p.path(); path != "" {
+
+		loaded, err := kman.LoadConfig(fs, path)
+
+		if err != nil {
+			return config, fmt.Errorf("Error 00: %s", err)
+		}
+
+		config = loaded
+	}
+
+	return config.WithOverrides(p.overrides()), nil
+}
+
+// overrides returns the settings of the flags given explicitly.
+func (p *project) overrides() (o kman.ConfigOverrides) {
+
+	p.flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "go":
+			o.Go = p.parseGo
+
+		case "md":
+			o.Markdown = p.parseMd
+
+		case "theme":
+			o.Theme = p.theme
+
+		case "output":
+			o.Output = p.output
+
+		case "cache":
+			noCache := !*p.useCache
+			o.NoCache = &noCache
+
+		case "version":
+			o.Version = p.version
+
+		case "var":
+			o.Vars = p.vars
+
+		case "audience":
+			o.Audience = []string{}
+
+			for _, audience := range strings.Split(*p.audience, ",") {
+				if audience = strings.TrimSpace(audience); audience != "" {
+					o.Audience = append(o.Audience, audience)
+				}
+			}
+
+		case "exclude-drafts":
+			o.ExcludeDrafts = p.noDrafts
+		}
+	})
+
+	return
+}
+
+func cache(config kman.Config) kman.Cache {
+
+	if config.NoCache {
+		return nil
+	}
+
+	return manifests(config)
+}
+
+// manifests keeps the lists of files written by builds in the cache
+// directory, even when the cache is off, so that stale files are removed.
+func manifests(config kman.Config) kman.Cache {
+
+	if config.Cache == "" {
+		return nil
+	}
+
+	return kman.NewCacheFromLocalFilesystem(config.Cache)
+}
+
+func document(config kman.Config) (kman.Documentation, error) {
+
+	variables, err := config.Variables()
+
+	if err != nil {
+		return kman.Documentation{}, fmt.Errorf("Error 00: %s", err)
+	}
+
+	docker := kman.NewDefaultDocumenterWithOptions(
+		kman.NewDefaultSorter(),
+		config.DocumenterOptions(),
+		config.NewAssemblers(afero.NewOsFs(), cache(config), &variables)...,
+	)
+
+	doc, err := docker.Document()
+
+	if err != nil {
+		return doc, fmt.Errorf("Error 01: %s", err)
+	}
+
+	return doc.WithMarkdownOptions(config.Markdown), nil
+}
+
+// render writes the documentation to output. Pages unchanged since the last
+// build are skipped if a cache is given, and files the last build wrote but
+// this one didn't are removed if manifests are. Either may be nil.
+func render(config kman.Config, doc kman.Documentation, fs afero.Fs, output string, cache, manifests kman.Cache) error {
+
+	theme, err := kman.LoadThemeWithOverrides(afero.NewOsFs(), config.Theme, config.Overrides)
+
+	if err != nil {
+		return fmt.Errorf("Error 02: %s", err)
+	}
+
+	modTime := kman.FileModTimes(afero.NewOsFs())
+
+	if config.LastModified == kman.LastModifiedGit {
+		if modTime, err = kman.GitModTimes("."); err != nil {
+			return fmt.Errorf("Error 02: %s", err)
+		}
+	}
+
+	for _, name := range config.Renderers {
+
+		var renderer kman.Renderer
+
+		switch name {
+		case kman.RendererTypeAce:
+			renderer = kman.NewRendererAceWithOptions(
+				fs,
+				config.Theme,
+				output,
+				kman.RendererOptions{
+					Site:          config.Site,
+					Theme:         theme,
+					Fingerprint:   config.Fingerprint,
+					BaseURL:       config.BaseURL,
+					RelativeLinks: config.RelativeLinks,
+					ModTime:       modTime,
+					Disallow:      config.Robots.Disallow,
+					Cache:         cache,
+					Manifests:     manifests,
+				},
+			)
+		}
+
+		if err := renderer.Render(doc); err != nil {
+			return fmt.Errorf("Error 02: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func build(config kman.Config) error {
+
+	doc, err := document(config)
+
+	if err != nil {
+		return err
+	}
+
+	return render(config, doc, afero.NewOsFs(), config.Output, cache(config), manifests(config))
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/kowala-tech/kman"
+	"github.com/spf13/afero"
+)
+
+func serveCommand(flags *flag.FlagSet) func() error {
+
+	project := projectFlags(flags)
+	httpAddress := flags.String("http", ":8080", "Address to serve http on")
+	watchFiles := flags.Bool("watch", true, "Rebuild when a source, theme or config file changes")
+
+	return func() error {
+		return serve(project, *httpAddress, *watchFiles)
+	}
+}
+
+func serve(project *project, address string, watchFiles bool) error {
+
+	site := &site{
+		project: project,
+		reload:  kman.NewLiveReloadHandler(),
+	}
+
+	if watchFiles {
+
+		w, err := newWatcher(site.rebuild)
+
+		if err != nil {
+			return err
+		}
+
+		defer w.close()
+
+		site.watcher = w
+	}
+
+	site.rebuild()
+
+	if site.err != nil && !watchFiles {
+		return site.err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(kman.LiveReloadPath, site.reload)
+	mux.Handle(kman.LiveReloadScriptPath, site.reload)
+	mux.Handle("/", site)
+
+	log.Printf("Serving documentation on %s\n", address)
+
+	return http.ListenAndServe(address, mux)
+}
+
+// site serves the last good build from memory, while rebuilds run in the
+// background.
+type site struct {
+	project *project
+	watcher *watcher
+	reload  kman.LiveReloadHandler
+
+	mu      sync.RWMutex
+	handler http.Handler
+	err     error
+
+	// includes are the files included by the topics and terms of the last
+	// build which read its sources.
+	includes []string
+}
+
+func (s *site) rebuild() {
+
+	err := s.build()
+
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+
+	if err != nil {
+		log.Printf("Build failed: %s\n", err)
+	} else {
+		log.Println("Build finished")
+	}
+
+	s.watch()
+	s.reload.Notify(err)
+}
+
+func (s *site) build() error {
+
+	config, err := s.project.config()
+
+	if err != nil {
+		return err
+	}
+
+	doc, err := document(config)
+
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.includes = doc.Includes()
+	s.mu.Unlock()
+
+	// Nothing is written to disk. Every page is rendered, as the previous
+	// build is not kept in memory.
+	output := afero.NewMemMapFs()
+
+	if err := render(config, doc, output, config.Output, nil, nil); err != nil {
+		return err
+	}
+
+	if s.watcher != nil {
+		if err := kman.InjectLiveReload(output, config.Output); err != nil {
+			return err
+		}
+	}
+
+	static, err := kman.NewStaticHandler(output, config.Output)
+
+	if err != nil {
+		return err
+	}
+
+	api, err := kman.NewAPIHandler(doc)
+
+	if err != nil {
+		return err
+	}
+
+	// Each build gets its own routes, so the API always answers from the
+	// same documentation as the pages being served.
+	handler := http.NewServeMux()
+	handler.Handle("/api/", api)
+	handler.Handle("/api/search", kman.NewSearchHandler(kman.NewSearchIndex(doc)))
+	handler.Handle("/", static)
+
+	s.mu.Lock()
+	s.handler = underBasePath(config.BaseURL, handler)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// watch points the watcher at the files used by the current config, which
+// may have changed since the last build, and at the files included by the
+// last build which read the sources.
+func (s *site) watch() {
+
+	if s.watcher == nil {
+		return
+	}
+
+	config, err := s.project.config()
+
+	if err != nil {
+		return
+	}
+
+	s.mu.RLock()
+	includes := s.includes
+	s.mu.RUnlock()
+
+	if err := s.watcher.watch(s.project.path(), config, includes); err != nil {
+		log.Printf("Watching files failed: %s\n", err)
+	}
+}
+
+func (s *site) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	s.mu.RLock()
+	handler, err := s.handler, s.err
+	s.mu.RUnlock()
+
+	if handler == nil {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(errorPage(err))
+		return
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// underBasePath serves the site under the path of its base URL, if any, as
+// its links expect.
+func underBasePath(baseURL string, handler http.Handler) http.Handler {
+
+	u, err := url.Parse(baseURL)
+
+	if err != nil || strings.Trim(u.Path, "/") == "" {
+		return handler
+	}
+
+	base := "/" + strings.Trim(u.Path, "/")
+
+	mux := http.NewServeMux()
+	mux.Handle(base+"/", http.StripPrefix(base, handler))
+	mux.Handle("/", http.RedirectHandler(base+"/", http.StatusFound))
+
+	return mux
+}
+
+// errorPage is served when no build has succeeded yet.
+func errorPage(err error) []byte {
+	return []byte(fmt.Sprintf(
+		"<!DOCTYPE html><html><head><title>Build failed</title></head><body><h2>Build failed</h2><pre>%s</pre>%s</body></html>",
+		html.EscapeString(err.Error()),
+		kman.LiveReloadTag,
+	))
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/kowala-tech/kman"
+)
+
+func showCommand(flags *flag.FlagSet) func() error {
+
+	project := projectFlags(flags)
+	showFiles := flags.Bool("files", false, "Show the file each item was found in")
+	showGlossary := flags.Bool("glossary", true, "Show the glossary")
+
+	return func() error {
+
+		config, err := project.config()
+
+		if err != nil {
+			return err
+		}
+
+		doc, err := document(config)
+
+		if err != nil {
+			return err
+		}
+
+		showTopic(os.Stdout, "/", 0, doc.RootTopic, *showFiles)
+
+		if *showGlossary && len(doc.Glossary) > 0 {
+
+			fmt.Fprintln(os.Stdout, "\nGlossary")
+
+			for _, term := range doc.Glossary {
+				showItem(os.Stdout, 1, term.Handle, term.Item, *showFiles)
+			}
+		}
+
+		return nil
+	}
+}
+
+func showTopic(w io.Writer, url string, depth int, topic kman.TopicRef, files bool) {
+
+	showItem(w, depth, url, topic.Item, files)
+
+	for _, child := range topic.Children {
+		showTopic(w, path.Join(url, child.Handle), depth+1, child, files)
+	}
+}
+
+func showItem(w io.Writer, depth int, handle string, item kman.Item, files bool) {
+
+	fmt.Fprintf(w, "%s%-24s %s", strings.Repeat("  ", depth), handle, item.Title)
+
+	if files {
+		fmt.Fprintf(w, " (%s)", item.FileName)
+	}
+
+	fmt.Fprintln(w)
+}
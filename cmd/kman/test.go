@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/kowala-tech/kman"
+)
+
+func testCommand(flags *flag.FlagSet) func() error {
+
+	project := projectFlags(flags)
+	timeout := flags.Duration("timeout", kman.DefaultExampleTimeout, "How long each example may run")
+
+	return func() error {
+
+		config, err := project.config()
+
+		if err != nil {
+			return err
+		}
+
+		doc, err := document(config)
+
+		if err != nil {
+			return err
+		}
+
+		examples := doc.Examples()
+		problems := kman.NewDefaultTester(kman.TesterOptions{Timeout: *timeout}).Test(doc)
+
+		for _, p := range problems {
+			log.Println(p)
+		}
+
+		if len(problems) > 0 {
+			return fmt.Errorf("%d of %d example(s) failed", len(problems), len(examples))
+		}
+
+		log.Printf("%d example(s) passed\n", len(examples))
+
+		return nil
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/kowala-tech/kman"
+	"github.com/spf13/afero"
+)
+
+func themeCommand(flags *flag.FlagSet) func() error {
+
+	output := flags.String("o", "themes/kman", "Directory to extract the theme to")
+	force := flags.Bool("force", false, "Extract into a directory that already exists")
+
+	return func() error {
+
+		if flags.NArg() == 0 || flags.Arg(0) != "extract" {
+			return fmt.Errorf("usage: kman theme extract [-o dir] [-force]")
+		}
+
+		// Flags may also follow the subcommand.
+		if err := flags.Parse(flags.Args()[1:]); err != nil {
+			return err
+		}
+
+		fs := afero.NewOsFs()
+
+		if exists, _ := afero.Exists(fs, *output); exists && !*force {
+			return fmt.Errorf("%s already exists, use -force to extract into it", *output)
+		}
+
+		if err := kman.ExtractTheme(kman.BuiltinTheme(), fs, *output); err != nil {
+			return err
+		}
+
+		log.Printf("Theme extracted to %s, use it with -theme %s or 'theme: %s' in the config\n", *output, *output, *output)
+
+		return nil
+	}
+}
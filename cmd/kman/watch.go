@@ -0,0 +1,144 @@
+package main
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/kowala-tech/kman"
+	"github.com/spf13/afero"
+)
+
+// watchDebounce groups the bursts of events editors produce on save.
+const watchDebounce = 200 * time.Millisecond
+
+// watcher calls changed whenever a source, theme or config file changes.
+type watcher struct {
+	fsw     *fsnotify.Watcher
+	changed func()
+
+	mu   sync.Mutex
+	dirs map[string]bool
+	set  kman.WatchSet
+}
+
+func newWatcher(changed func()) (*watcher, error) {
+
+	fsw, err := fsnotify.NewWatcher()
+
+	if err != nil {
+		return nil, err
+	}
+
+	w := &watcher{
+		fsw:     fsw,
+		changed: changed,
+		dirs:    make(map[string]bool),
+	}
+
+	go w.run()
+
+	return w, nil
+}
+
+func (w *watcher) close() error {
+	return w.fsw.Close()
+}
+
+// watch replaces the watched directories with those used by the config, and
+// holding the files included by topics and terms.
+func (w *watcher) watch(configPath string, config kman.Config, includes []string) error {
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.set = kman.NewWatchSet(afero.NewOsFs(), configPath, config).WithIncludes(includes)
+
+	dirs, err := w.set.Dirs(afero.NewOsFs())
+
+	for dir := range w.dirs {
+		if !dirs[dir] {
+			w.fsw.Remove(dir)
+		}
+	}
+
+	for dir := range dirs {
+		if !w.dirs[dir] {
+			if addErr := w.fsw.Add(dir); addErr != nil {
+				err = addErr
+			}
+		}
+	}
+
+	w.dirs = dirs
+
+	return err
+}
+
+// relevant reports whether a change to the file affects the build.
+func (w *watcher) relevant(path string) bool {
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.set.Relevant(path)
+}
+
+func (w *watcher) run() {
+
+	var timer <-chan time.Time
+
+	for {
+		select {
+		case event, ok := <-w.fsw.Events:
+
+			if !ok {
+				return
+			}
+
+			if event.Op&fsnotify.Create != 0 {
+				w.addDir(event.Name)
+			}
+
+			if w.relevant(event.Name) {
+				timer = time.After(watchDebounce)
+			}
+
+		case _, ok := <-w.fsw.Errors:
+
+			if !ok {
+				return
+			}
+
+		case <-timer:
+			timer = nil
+			w.changed()
+		}
+	}
+}
+
+// addDir starts watching directories created after the watcher started.
+func (w *watcher) addDir(path string) {
+
+	info, err := os.Stat(path)
+
+	if err != nil || !info.IsDir() {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	dirs, err := w.set.DirsBelow(afero.NewOsFs(), path)
+
+	if err != nil {
+		return
+	}
+
+	for dir := range dirs {
+		if !w.dirs[dir] && w.fsw.Add(dir) == nil {
+			w.dirs[dir] = true
+		}
+	}
+}
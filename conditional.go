@@ -0,0 +1,105 @@
+package kman
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// conditionalDirective is {{< if audience="a,b" >}}, {{< else >}} or
+// {{< end >}}, which may be nested.
+var conditionalDirective = regexp.MustCompile(`\{\{<\s*(if|else|end)((?:\s+\w+="[^"]*")*)\s*>\}\}`)
+
+// conditional is an if block being read: whether its condition holds, and
+// whether the content around it is kept.
+type conditional struct {
+	holds, outer, inElse bool
+}
+
+// conditionalContent keeps the branches of the conditional blocks of the
+// content of item whose conditions hold for the build, and drops the others.
+func (o DocumenterOptions) conditionalContent(item *Item) error {
+
+	content := item.Content
+
+	if !strings.Contains(content, "{{<") {
+		return nil
+	}
+
+	var (
+		output = newLineWriter(*item)
+		blocks []conditional
+		keep   = true
+		last   = 0
+	)
+
+	for _, m := range conditionalDirective.FindAllStringSubmatchIndex(content, -1) {
+
+		if keep {
+			output.copy(last, m[0])
+		}
+
+		last = m[1]
+
+		switch content[m[2]:m[3]] {
+		case "if":
+			holds, err := o.condition(content[m[4]:m[5]])
+
+			if err != nil {
+				return err
+			}
+
+			blocks = append(blocks, conditional{holds: holds, outer: keep})
+			keep = keep && holds
+
+		case "else":
+			if len(blocks) == 0 || blocks[len(blocks)-1].inElse {
+				return fmt.Errorf("{{< else >}} without {{< if >}}")
+			}
+
+			block := &blocks[len(blocks)-1]
+			block.inElse = true
+			keep = block.outer && !block.holds
+
+		case "end":
+			if len(blocks) == 0 {
+				return fmt.Errorf("{{< end >}} without {{< if >}}")
+			}
+
+			keep = blocks[len(blocks)-1].outer
+			blocks = blocks[:len(blocks)-1]
+		}
+	}
+
+	if len(blocks) > 0 {
+		return fmt.Errorf("{{< if >}} without {{< end >}}")
+	}
+
+	output.finish(item, last)
+
+	return nil
+}
+
+// condition reads the options of an if block, which must all hold.
+func (o DocumenterOptions) condition(options string) (bool, error) {
+
+	found := includeOption.FindAllStringSubmatch(options, -1)
+
+	if len(found) == 0 {
+		return false, fmt.Errorf("{{< if >}} without a condition")
+	}
+
+	holds := true
+
+	for _, option := range found {
+		switch option[1] {
+		case "audience":
+			holds = holds && o.isFor(strings.Split(option[2], ","))
+
+		default:
+			return false, fmt.Errorf("{{< if >}}: unknown condition %q", option[1])
+		}
+	}
+
+	return holds, nil
+}
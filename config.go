@@ -0,0 +1,445 @@
+package kman
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/afero"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	AssemblerTypeGo       = "go"
+	AssemblerTypeMarkdown = "markdown"
+
+	RendererTypeAce = "ace"
+)
+
+// ConfigFileNames lists the project config files looked up by FindConfig, in
+// order of preference.
+var ConfigFileNames = []string{"kman.yaml", "kman.yml", "kman.toml"}
+
+// Config describes a documentation project, so that it can be committed
+// alongside the sources instead of being passed as command line flags.
+type Config struct {
+	Site Site `yaml:"site" toml:"site" json:"site"`
+
+	// Theme is a theme directory or archive. Empty for the built-in theme.
+	Theme string `yaml:"theme" toml:"theme" json:"theme"`
+
+	// Overrides are directories laid over the theme, so that single
+	// templates or assets can be replaced. The first one holding a file wins.
+	Overrides []string `yaml:"overrides,omitempty" toml:"overrides,omitempty" json:"overrides,omitempty"`
+
+	// BaseURL is where the site is hosted, such as
+	// https://example.github.io/project/, so that it can live under a path.
+	BaseURL string `yaml:"baseURL,omitempty" toml:"baseURL,omitempty" json:"baseURL,omitempty"`
+
+	// RelativeLinks makes the links of each page relative to it, so that the
+	// site can be opened from disk.
+	RelativeLinks bool `yaml:"relativeLinks,omitempty" toml:"relativeLinks,omitempty" json:"relativeLinks,omitempty"`
+
+	// LastModified is where the sitemap takes page dates from: source file
+	// times by default, or git history.
+	LastModified string `yaml:"lastModified,omitempty" toml:"lastModified,omitempty" json:"lastModified,omitempty"`
+
+	Robots Robots `yaml:"robots,omitempty" toml:"robots,omitempty" json:"robots,omitempty"`
+
+	// Fingerprint names assets after a hash of their content, so that they
+	// can be cached for ever.
+	Fingerprint bool `yaml:"fingerprint" toml:"fingerprint" json:"fingerprint"`
+
+	// Markdown turns extensions of the markdown syntax on or off. Those not
+	// mentioned keep their defaults.
+	Markdown MarkdownOptions `yaml:"markdown" toml:"markdown" json:"markdown"`
+
+	// Version is what topics get as {{ .Version }}, or "git" for the latest
+	// git tag. Vars are what they get as {{ .Vars.name }}.
+	Version string            `yaml:"version,omitempty" toml:"version,omitempty" json:"version,omitempty"`
+	Vars    map[string]string `yaml:"vars,omitempty" toml:"vars,omitempty" json:"vars,omitempty"`
+
+	// Audience and ExcludeDrafts pick the items of a build, as described
+	// by DocumenterOptions.
+	Audience      []string `yaml:"audience,omitempty" toml:"audience,omitempty" json:"audience,omitempty"`
+	ExcludeDrafts bool     `yaml:"excludeDrafts,omitempty" toml:"excludeDrafts,omitempty" json:"excludeDrafts,omitempty"`
+
+	Output     string            `yaml:"output" toml:"output" json:"output"`
+	Cache      string            `yaml:"cache" toml:"cache" json:"cache"`
+	Assemblers []AssemblerConfig `yaml:"assemblers" toml:"assemblers" json:"assemblers"`
+	Renderers  []string          `yaml:"renderers" toml:"renderers" json:"renderers"`
+
+	// NoCache turns the cache off, as only the command line does. The cache
+	// directory still keeps the list of files written by the last build.
+	NoCache bool `yaml:"-" toml:"-" json:"-"`
+}
+
+// Site holds the metadata made available to themes.
+type Site struct {
+	Title       string `yaml:"title" toml:"title" json:"title"`
+	Description string `yaml:"description" toml:"description" json:"description"`
+	Author      string `yaml:"author" toml:"author" json:"author"`
+}
+
+// Robots configures the generated robots.txt.
+type Robots struct {
+	Disallow []string `yaml:"disallow" toml:"disallow" json:"disallow"`
+}
+
+type AssemblerConfig struct {
+	Type    string   `yaml:"type" toml:"type" json:"type"`
+	Root    string   `yaml:"root" toml:"root" json:"root"`
+	Exclude []string `yaml:"exclude" toml:"exclude" json:"exclude"`
+}
+
+func (a AssemblerConfig) Options() AssemblerOptions {
+
+	options := AssemblerOptions{
+		Root:    a.Root,
+		Exclude: a.Exclude,
+	}
+
+	options.Root = options.root()
+
+	return options
+}
+
+// Reads reports whether the assembler would read the file at path, so that
+// watchers can ignore unrelated changes.
+func (a AssemblerConfig) Reads(path string) bool {
+
+	options := a.Options()
+
+	rel, err := filepath.Rel(options.Root, path)
+
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+
+	for dir := path; dir != "." && dir != options.Root && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		if options.Excludes(dir) {
+			return false
+		}
+	}
+
+	return hasSourceExtension(a.Type, path)
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Site: Site{
+			Title: "K-man docs",
+		},
+		Markdown: DefaultMarkdownOptions(),
+		Output:   "public",
+		Cache:    ".kman/cache",
+		Assemblers: []AssemblerConfig{
+			AssemblerConfig{
+				Type:    AssemblerTypeMarkdown,
+				Root:    ".",
+				Exclude: []string{".git", ".kman", "vendor", "public"},
+			},
+		},
+		Renderers: []string{RendererTypeAce},
+	}
+}
+
+// FindConfig returns the first project config file present in the working
+// directory of the given filesystem.
+func FindConfig(fs afero.Fs) (string, bool) {
+
+	for _, name := range ConfigFileNames {
+		if exists, _ := afero.Exists(fs, name); exists {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// LoadConfig reads a YAML or TOML project config. Settings missing from the
+// file keep their DefaultConfig values.
+func LoadConfig(fs afero.Fs, path string) (Config, error) {
+
+	// Booleans can't tell unset from false, so those defaulting to true are
+	// set before decoding.
+	config := Config{
+		Markdown: DefaultMarkdownOptions(),
+	}
+
+	data, err := afero.ReadFile(fs, path)
+
+	if err != nil {
+		return DefaultConfig(), err
+	}
+
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(data, &config)
+
+	case ".toml":
+		err = decodeTOMLStrict(string(data), &config)
+
+	default:
+		err = fmt.Errorf("unknown config format %q", filepath.Ext(path))
+	}
+
+	if err != nil {
+		return DefaultConfig(), fmt.Errorf("%s: %s", path, err)
+	}
+
+	config.setDefaults()
+
+	return config, config.Validate()
+}
+
+// decodeTOMLStrict decodes TOML, failing on keys which match no setting, as
+// yaml.UnmarshalStrict does for YAML.
+func decodeTOMLStrict(data string, config *Config) error {
+
+	md, err := toml.Decode(data, config)
+
+	if err != nil {
+		return err
+	}
+
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+
+		keys := make([]string, len(undecoded))
+
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+
+		return fmt.Errorf("unknown settings: %s", strings.Join(keys, ", "))
+	}
+
+	return nil
+}
+
+func (c *Config) setDefaults() {
+
+	defaults := DefaultConfig()
+
+	if c.Site.Title == "" {
+		c.Site.Title = defaults.Site.Title
+	}
+
+	if c.Output == "" {
+		c.Output = defaults.Output
+	}
+
+	if c.Cache == "" {
+		c.Cache = defaults.Cache
+	}
+
+	if c.Assemblers == nil {
+		c.Assemblers = defaults.Assemblers
+	}
+
+	if c.Renderers == nil {
+		c.Renderers = defaults.Renderers
+	}
+}
+
+// ConfigOverrides are settings given on the command line, which win over
+// those of the config file. Nil fields leave the config as it is.
+type ConfigOverrides struct {
+	// Go and Markdown add an assembler of their type reading from the
+	// working directory, or remove those of the config.
+	Go       *bool
+	Markdown *bool
+
+	Theme  *string
+	Output *string
+
+	// NoCache turns the cache off, if true.
+	NoCache *bool
+
+	Version *string
+
+	// Vars are added to those of the config, replacing any of the same name.
+	Vars map[string]string
+
+	Audience      []string
+	ExcludeDrafts *bool
+}
+
+// WithOverrides returns a copy of the config changed by overrides.
+func (c Config) WithOverrides(o ConfigOverrides) Config {
+
+	if o.Theme != nil {
+		c.Theme = *o.Theme
+	}
+
+	if o.Output != nil {
+		c.Output = *o.Output
+	}
+
+	if o.Go != nil {
+		c.Assemblers = c.toggleAssembler(AssemblerTypeGo, *o.Go)
+	}
+
+	if o.Markdown != nil {
+		c.Assemblers = c.toggleAssembler(AssemblerTypeMarkdown, *o.Markdown)
+	}
+
+	if o.NoCache != nil {
+		c.NoCache = *o.NoCache
+	}
+
+	if o.Version != nil {
+		c.Version = *o.Version
+	}
+
+	if o.Vars != nil {
+
+		vars := map[string]string{}
+
+		for name, value := range c.Vars {
+			vars[name] = value
+		}
+
+		for name, value := range o.Vars {
+			vars[name] = value
+		}
+
+		c.Vars = vars
+	}
+
+	if o.Audience != nil {
+		c.Audience = o.Audience
+	}
+
+	if o.ExcludeDrafts != nil {
+		c.ExcludeDrafts = *o.ExcludeDrafts
+	}
+
+	return c
+}
+
+// toggleAssembler removes the assemblers of a type, or adds one reading from
+// the working directory if there is none.
+func (c Config) toggleAssembler(typ string, enabled bool) (output []AssemblerConfig) {
+
+	found := false
+
+	for _, a := range c.Assemblers {
+
+		if a.Type == typ {
+			found = true
+
+			if !enabled {
+				continue
+			}
+		}
+
+		output = append(output, a)
+	}
+
+	if enabled && !found {
+		output = append(output, AssemblerConfig{
+			Type:    typ,
+			Root:    ".",
+			Exclude: []string{".git", ".kman", "vendor", c.Output},
+		})
+	}
+
+	return
+}
+
+// DocumenterOptions returns the filters of a build.
+func (c Config) DocumenterOptions() DocumenterOptions {
+	return DocumenterOptions{
+		Audience:      c.Audience,
+		ExcludeDrafts: c.ExcludeDrafts,
+	}
+}
+
+// Variables returns the values topics may refer to, reading the version from
+// git if the config says so.
+func (c Config) Variables() (Variables, error) {
+
+	variables := Variables{Version: c.Version, Vars: c.Vars}
+
+	if c.Version == VersionGit {
+
+		version, err := GitVersion(".")
+
+		if err != nil {
+			return variables, fmt.Errorf("version: %s", err)
+		}
+
+		variables.Version = version
+	}
+
+	return variables, nil
+}
+
+func (c Config) Validate() error {
+
+	for _, a := range c.Assemblers {
+		switch a.Type {
+		case AssemblerTypeGo, AssemblerTypeMarkdown:
+		default:
+			return fmt.Errorf("unknown assembler type %q", a.Type)
+		}
+	}
+
+	switch c.LastModified {
+	case "", LastModifiedFile, LastModifiedGit:
+	default:
+		return fmt.Errorf("unknown lastModified source %q", c.LastModified)
+	}
+
+	for _, r := range c.Renderers {
+		switch r {
+		case RendererTypeAce:
+		default:
+			return fmt.Errorf("unknown renderer type %q", r)
+		}
+	}
+
+	return nil
+}
+
+// Marshal encodes the config in the format implied by the path extension.
+func (c Config) Marshal(path string) ([]byte, error) {
+
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		return yaml.Marshal(c)
+
+	case ".toml":
+		var buf bytes.Buffer
+		err := toml.NewEncoder(&buf).Encode(c)
+		return buf.Bytes(), err
+	}
+
+	return nil, fmt.Errorf("unknown config format %q", filepath.Ext(path))
+}
+
+// NewAssemblers creates the assemblers declared in the config, reading from
+// fs, with the given variables for topics to refer to. The cache and the
+// variables may be nil.
+func (c Config) NewAssemblers(fs afero.Fs, cache Cache, variables *Variables) (assemblers []Assembler) {
+
+	for _, a := range c.Assemblers {
+
+		options := a.Options()
+		options.Cache = cache
+		options.Variables = variables
+
+		switch a.Type {
+		case AssemblerTypeGo:
+			assemblers = append(assemblers, NewGoAssemblerWithOptions(fs, options))
+
+		case AssemblerTypeMarkdown:
+			assemblers = append(assemblers, NewMarkdownAssemblerWithOptions(fs, options))
+		}
+	}
+
+	return
+}
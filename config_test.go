@@ -0,0 +1,232 @@
+package kman
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/endiangroup/snaptest"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AConfigCanBeLoadedFromAFile(t *testing.T) {
+
+	for cycle, test := range []struct {
+		description string
+
+		path    string
+		content string
+		err     bool
+	}{
+		{
+			description: "YAML",
+			path:        "kman.yaml",
+			content: `
+site:
+  title: My docs
+output: docs
+version: v1.2.0
+vars:
+  chainID: "519374298533"
+markdown:
+  footnotes: true
+  smartypants: false
+assemblers:
+  - type: go
+    root: src
+    exclude: [vendor]
+`,
+		},
+		{
+			description: "TOML",
+			path:        "kman.toml",
+			content: `
+theme = "themes/other"
+
+[markdown]
+taskLists = true
+
+[[assemblers]]
+type = "markdown"
+exclude = ["public", "*.draft.md"]
+`,
+		},
+		{
+			description: "Unknown setting",
+			path:        "kman.yaml",
+			content:     "outptu: docs",
+			err:         true,
+		},
+		{
+			description: "Unknown TOML setting",
+			path:        "kman.toml",
+			content:     "outptu = \"docs\"\n\n[markdown]\nfootnote = true",
+			err:         true,
+		},
+		{
+			description: "Unknown assembler",
+			path:        "kman.yml",
+			content:     "assemblers: [{type: rst}]",
+			err:         true,
+		},
+	} {
+		t.Run(fmt.Sprintf("Cycle %d: %s", cycle, test.description), func(t *testing.T) {
+
+			fs := newMockFilesystem(t, map[string]string{test.path: test.content})
+
+			path, found := FindConfig(fs)
+			require.True(t, found)
+			require.Equal(t, test.path, path)
+
+			config, err := LoadConfig(fs, path)
+
+			if test.err {
+				require.NotNil(t, err)
+				return
+			}
+
+			require.Nil(t, err)
+			snaptest.Snapshot(t, config)
+		})
+	}
+}
+
+func Test_ATOMLConfigShouldListTheUnknownSettings(t *testing.T) {
+
+	fs := newMockFilesystem(t, map[string]string{"kman.toml": "outptu = \"docs\"\n\n[markdown]\nfootnote = true"})
+
+	_, err := LoadConfig(fs, "kman.toml")
+	require.EqualError(t, err, "kman.toml: unknown settings: outptu, markdown.footnote")
+}
+
+func Test_ADefaultConfigSurvivesARoundTrip(t *testing.T) {
+
+	for _, path := range []string{"kman.yaml", "kman.toml"} {
+
+		data, err := DefaultConfig().Marshal(path)
+		require.Nil(t, err)
+
+		fs := newMockFilesystem(t, map[string]string{path: string(data)})
+
+		config, err := LoadConfig(fs, path)
+		require.Nil(t, err)
+		require.Equal(t, DefaultConfig(), config)
+	}
+}
+
+func Test_TheRepositorysOwnDocsShouldBuildWithTheDefaultConfig(t *testing.T) {
+
+	config := DefaultConfig()
+
+	variables, err := config.Variables()
+	require.Nil(t, err)
+
+	doc, err := NewDefaultDocumenterWithOptions(
+		NewDefaultSorter(),
+		config.DocumenterOptions(),
+		config.NewAssemblers(afero.NewOsFs(), nil, &variables)...,
+	).Document()
+
+	require.Nil(t, err)
+	require.Equal(t, "Home", doc.RootTopic.Title)
+}
+
+func Test_AnAssemblerConfigKnowsWhichFilesItReads(t *testing.T) {
+
+	config := AssemblerConfig{
+		Type:    AssemblerTypeMarkdown,
+		Root:    "docs",
+		Exclude: []string{"public", "*.draft.md"},
+	}
+
+	for path, reads := range map[string]bool{
+		"docs/topics.md":          true,
+		"docs/nested/terms.md":    true,
+		"docs/topics.go":          false,
+		"docs/notes.draft.md":     false,
+		"docs/public/index.md":    false,
+		"docs/public/nested/a.md": false,
+		"other/topics.md":         false,
+		"topics.md":               false,
+	} {
+		require.Equal(t, reads, config.Reads(path), path)
+	}
+}
+
+func Test_OverridesShouldWinOverTheConfig(t *testing.T) {
+
+	on, off, theme, output, version := true, false, "themes/mine", "site", "v2.0.0"
+
+	config := DefaultConfig()
+	config.Vars = map[string]string{"a": "1", "b": "2"}
+	config.Audience = []string{"operator"}
+
+	for i, c := range []struct {
+		description string
+		overrides   ConfigOverrides
+		check       func(t *testing.T, config Config)
+	}{
+		{
+			"Nothing overridden",
+			ConfigOverrides{},
+			func(t *testing.T, overridden Config) {
+				require.Equal(t, config, overridden)
+			},
+		},
+		{
+			"Settings",
+			ConfigOverrides{Theme: &theme, Output: &output, Version: &version, NoCache: &on, ExcludeDrafts: &on},
+			func(t *testing.T, config Config) {
+				require.Equal(t, theme, config.Theme)
+				require.Equal(t, output, config.Output)
+				require.Equal(t, version, config.Version)
+				require.True(t, config.NoCache)
+				require.Equal(t, DefaultConfig().Cache, config.Cache)
+				require.True(t, config.ExcludeDrafts)
+			},
+		},
+		{
+			"Cache left on",
+			ConfigOverrides{NoCache: &off},
+			func(t *testing.T, config Config) {
+				require.False(t, config.NoCache)
+				require.Equal(t, DefaultConfig().Cache, config.Cache)
+			},
+		},
+		{
+			"Variables merged",
+			ConfigOverrides{Vars: map[string]string{"b": "3", "c": "4"}},
+			func(t *testing.T, config Config) {
+				require.Equal(t, map[string]string{"a": "1", "b": "3", "c": "4"}, config.Vars)
+			},
+		},
+		{
+			"Audience replaced",
+			ConfigOverrides{Audience: []string{}},
+			func(t *testing.T, config Config) {
+				require.Empty(t, config.Audience)
+			},
+		},
+		{
+			"Go assembler added, excluding the output",
+			ConfigOverrides{Go: &on, Output: &output},
+			func(t *testing.T, config Config) {
+				require.Len(t, config.Assemblers, 2)
+				require.Equal(t, AssemblerConfig{Type: AssemblerTypeGo, Root: ".", Exclude: []string{".git", ".kman", "vendor", output}}, config.Assemblers[1])
+			},
+		},
+		{
+			"Markdown assembler removed",
+			ConfigOverrides{Markdown: &off},
+			func(t *testing.T, config Config) {
+				require.Empty(t, config.Assemblers)
+			},
+		},
+	} {
+		t.Run(fmt.Sprintf("Cycle %d: %s", i, c.description), func(t *testing.T) {
+			c.check(t, config.WithOverrides(c.overrides))
+		})
+	}
+
+	require.Equal(t, map[string]string{"a": "1", "b": "2"}, config.Vars)
+}
@@ -2,13 +2,36 @@ package kman
 
 import (
 	"html/template"
-
-	"github.com/russross/blackfriday"
 )
 
 type Documentation struct {
 	RootTopic TopicRef
 	Glossary  []TermRef
+
+	// Tags indexes the topics and terms by tag, in the order of the tags'
+	// names.
+	Tags []TagRef
+}
+
+// WithMarkdownOptions returns a copy of the documentation whose topics and
+// terms are rendered with the given options.
+func (d Documentation) WithMarkdownOptions(options MarkdownOptions) Documentation {
+
+	d.RootTopic = d.RootTopic.withMarkdownOptions(&options)
+
+	if d.Glossary != nil {
+
+		glossary := make([]TermRef, len(d.Glossary))
+
+		for i, term := range d.Glossary {
+			term.markdown = &options
+			glossary[i] = term
+		}
+
+		d.Glossary = glossary
+	}
+
+	return d
 }
 
 //go:generate stringer -type=ItemType
@@ -19,6 +42,17 @@ const (
 	ItemTypeTerm
 )
 
+// Name is the lower case name of the type, as used in URLs and APIs.
+func (t ItemType) Name() string {
+
+	switch t {
+	case ItemTypeTerm:
+		return "term"
+	}
+
+	return "topic"
+}
+
 type Item struct {
 	Type     ItemType
 	FileName string
@@ -26,10 +60,40 @@ type Item struct {
 	Title    string
 	Handle   string
 	Content  string
+
+	// Draft items are left out of builds excluding drafts, and items with
+	// an audience out of builds for other audiences.
+	Draft    bool
+	Audience []string
+	Tags     []string
+
+	// markdown is how Content is rendered, or nil for the defaults.
+	markdown *MarkdownOptions
+
+	// lines holds the line in FileName of each line of Content, when known.
+	lines []uint
+
+	// includes are the files Content includes snippets from.
+	includes []string
 }
 
+func (i Item) markdownOptions() MarkdownOptions {
+
+	if i.markdown == nil {
+		return DefaultMarkdownOptions()
+	}
+
+	return *i.markdown
+}
+
+// HTML renders the content of the item, with an ID on every heading.
 func (i Item) HTML() template.HTML {
-	return template.HTML(blackfriday.Run([]byte(i.Content)))
+	return parseMarkdown(i.Content, "", i.markdownOptions()).HTML()
+}
+
+// TOC lists the headings of the content of the item, as a tree.
+func (i Item) TOC() []Heading {
+	return parseMarkdown(i.Content, "", i.markdownOptions()).TOC()
 }
 
 type itemListHandleSorter []Item
@@ -51,8 +115,86 @@ func (r itemListTitleSorter) Less(i, j int) bool {
 type TopicRef struct {
 	Item
 	Children []TopicRef
+
+	// Backlinks are the other topics linking to this one, and Terms the
+	// glossary terms it links to or mentions.
+	Backlinks []PageRef
+	Terms     []PageRef
+}
+
+func (t TopicRef) withMarkdownOptions(options *MarkdownOptions) TopicRef {
+
+	t.markdown = options
+
+	if t.Children != nil {
+
+		children := make([]TopicRef, len(t.Children))
+
+		for i, child := range t.Children {
+			children[i] = child.withMarkdownOptions(options)
+		}
+
+		t.Children = children
+	}
+
+	return t
 }
 
 type TermRef struct {
 	Item
+
+	// UsedIn are the topics linking to the term or mentioning it.
+	UsedIn []PageRef
+}
+
+// HTML renders the content of the term. As every term shares the glossary
+// page, the IDs of its headings start with its handle.
+func (t TermRef) HTML() template.HTML {
+	return parseMarkdown(t.Content, t.Handle+"-", t.markdownOptions()).HTML()
+}
+
+// TOC lists the headings of the content of the term, as a tree.
+func (t TermRef) TOC() []Heading {
+	return parseMarkdown(t.Content, t.Handle+"-", t.markdownOptions()).TOC()
+}
+
+// TagRef is a tag, and the topics and terms carrying it, in the order of the
+// topic tree and of the glossary. Tags differing only in case or punctuation
+// share a handle, and so a TagRef.
+type TagRef struct {
+	Name   string
+	Handle string
+	Topics []PageRef
+	Terms  []PageRef
+}
+
+// Count is the number of topics and terms carrying the tag.
+func (t TagRef) Count() int {
+	return len(t.Topics) + len(t.Terms)
+}
+
+// has reports whether the page at url is already listed, as an item may
+// carry a tag twice under different spellings.
+func (t *TagRef) has(url string) bool {
+
+	for _, refs := range [][]PageRef{t.Topics, t.Terms} {
+		for _, ref := range refs {
+			if ref.URL == url {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// PageRef is a topic or term, and the URL of its page.
+type PageRef struct {
+	URL string
+	Item
+}
+
+// TagHandle turns a tag into the handle of its page.
+func TagHandle(tag string) string {
+	return headingID(tag)
 }
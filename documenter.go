@@ -1,5 +1,46 @@
 package kman
 
+import "strings"
+
 type Documenter interface {
 	Document() (Documentation, error)
 }
+
+// DocumenterOptions picks the items of a build, so that builds for several
+// audiences can be made from the same sources. The zero value keeps every
+// item.
+type DocumenterOptions struct {
+	// Audience leaves out the items and conditional blocks meant for other
+	// audiences. Items without an audience are for everyone.
+	Audience      []string
+	ExcludeDrafts bool
+}
+
+// Includes reports whether an item belongs in the build.
+func (o DocumenterOptions) Includes(item Item) bool {
+
+	if o.ExcludeDrafts && item.Draft {
+		return false
+	}
+
+	return item.Audience == nil || o.isFor(item.Audience)
+}
+
+// isFor reports whether the build is for one of the given audiences. A build
+// without an audience is for all of them.
+func (o DocumenterOptions) isFor(audience []string) bool {
+
+	if len(o.Audience) == 0 {
+		return true
+	}
+
+	for _, a := range audience {
+		for _, b := range o.Audience {
+			if strings.EqualFold(strings.TrimSpace(a), b) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
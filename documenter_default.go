@@ -1,30 +1,53 @@
 package kman
 
+import "fmt"
+
 type documenterDefault struct {
 	assemblers []Assembler
 	sorter     Sorter
+	options    DocumenterOptions
 }
 
 func NewDefaultDocumenter(sorter Sorter, assemblers ...Assembler) Documenter {
+	return NewDefaultDocumenterWithOptions(sorter, DocumenterOptions{}, assemblers...)
+}
+
+func NewDefaultDocumenterWithOptions(sorter Sorter, options DocumenterOptions, assemblers ...Assembler) Documenter {
 	return &documenterDefault{
 		assemblers: assemblers,
 		sorter:     sorter,
+		options:    options,
 	}
 }
 
 func (d *documenterDefault) Document() (Documentation, error) {
 
 	items := []Item{}
+	assembled := make([][]Item, len(d.assemblers))
 
-	for _, a := range d.assemblers {
-		assembled, err := a.Assemble()
+	err := parallel(len(d.assemblers), func(i int) (err error) {
+		assembled[i], err = d.assemblers[i].Assemble()
+		return
+	})
 
-		if err != nil {
-			return Documentation{}, err
-		}
+	if err != nil {
+		return Documentation{}, err
+	}
 
-		items = append(items, assembled...)
+	for _, a := range assembled {
+		for _, item := range a {
+
+			if !d.options.Includes(item) {
+				continue
+			}
+
+			if err = d.options.conditionalContent(&item); err != nil {
+				return Documentation{}, fmt.Errorf("%s: %s %q: %s", item.FileName, item.Type.Name(), item.Title, err)
+			}
+
+			items = append(items, item)
+		}
 	}
 
-	return d.sorter.Sort(items), nil
+	return crossReference(d.sorter.Sort(items)), nil
 }
@@ -1,6 +1,7 @@
 package kman
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/endiangroup/snaptest"
@@ -29,3 +30,74 @@ func Test_ADefaultDocumenterShouldBeAbleToDocument(t *testing.T) {
 	require.Nil(t, err)
 	snaptest.Snapshot(t, doc)
 }
+
+func Test_ADefaultDocumenterShouldFilterItems(t *testing.T) {
+
+	items := []Item{
+		{Type: ItemTypeTopic, Handle: "root", Title: "Root"},
+		{Type: ItemTypeTopic, Handle: "draft", Title: "Draft", Draft: true},
+		{Type: ItemTypeTopic, Handle: "operators", Title: "Operators", Audience: []string{"operator"}},
+		{Type: ItemTypeTerm, Handle: "partners", Title: "Partners", Audience: []string{"partner", "Internal"}},
+	}
+
+	for i, c := range []struct {
+		description string
+		options     DocumenterOptions
+		expected    []string
+	}{
+		{"Everything", DocumenterOptions{}, []string{"root", "draft", "operators", "partners"}},
+		{"No drafts", DocumenterOptions{ExcludeDrafts: true}, []string{"root", "operators", "partners"}},
+		{"Operators", DocumenterOptions{Audience: []string{"operator"}}, []string{"root", "draft", "operators"}},
+		{"Internal, without drafts", DocumenterOptions{Audience: []string{"internal"}, ExcludeDrafts: true}, []string{"root", "partners"}},
+	} {
+		t.Run(fmt.Sprintf("Cycle %d: %s", i, c.description), func(t *testing.T) {
+
+			var handles []string
+
+			for _, item := range items {
+				if c.options.Includes(item) {
+					handles = append(handles, item.Handle)
+				}
+			}
+
+			require.Equal(t, c.expected, handles)
+		})
+	}
+}
+
+func Test_ADefaultDocumenterShouldKeepTheConditionalContentOfTheBuild(t *testing.T) {
+
+	content := "A\n{{< if audience=\"operator,partner\" >}}\nB{{< if audience=\"partner\" >}} C{{< else >}} D{{< end >}}\n{{< else >}}\nE\n{{< end >}}\nF"
+
+	for i, c := range []struct {
+		description string
+		audience    []string
+		content     string
+		expected    string
+		err         string
+	}{
+		{"Everyone", nil, content, "A\n\nB C\n\nF", ""},
+		{"Operator", []string{"operator"}, content, "A\n\nB D\n\nF", ""},
+		{"Public", []string{"public"}, content, "A\n\nE\n\nF", ""},
+		{"Unclosed", nil, "{{< if audience=\"a\" >}}", "", "topics.md: topic \"Root\": {{< if >}} without {{< end >}}"},
+		{"Stray end", nil, "{{< end >}}", "", "topics.md: topic \"Root\": {{< end >}} without {{< if >}}"},
+		{"Unknown condition", nil, "{{< if tag=\"a\" >}}{{< end >}}", "", "topics.md: topic \"Root\": {{< if >}}: unknown condition \"tag\""},
+	} {
+		t.Run(fmt.Sprintf("Cycle %d: %s", i, c.description), func(t *testing.T) {
+
+			docer := NewDefaultDocumenterWithOptions(NewDefaultSorter(), DocumenterOptions{Audience: c.audience}, &mockAssembler{[]Item{
+				{Type: ItemTypeTopic, Handle: "root", Title: "Root", FileName: "topics.md", Content: c.content},
+			}})
+
+			doc, err := docer.Document()
+
+			if c.err != "" {
+				require.EqualError(t, err, c.err)
+				return
+			}
+
+			require.Nil(t, err)
+			require.Equal(t, c.expected, doc.RootTopic.Content)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package kman
+
+import (
+	"strings"
+)
+
+// Modes of runnable examples, given after the language of a fenced code
+// block, as in "```go test" or "```sh expect".
+const (
+	// ExampleModeTest compiles a Go snippet against the local module, and
+	// runs it.
+	ExampleModeTest = "test"
+
+	// ExampleModeExpect runs the commands of a shell transcript, each line
+	// starting with "$ ", and compares their output with the lines below.
+	ExampleModeExpect = "expect"
+)
+
+// Example is a fenced code block of a topic or term marked as runnable. Line
+// is where its opening fence is in the item's file, or within the content of
+// items which don't know where their content comes from.
+type Example struct {
+	Item     Item
+	Line     uint
+	Language string
+	Mode     string
+	Code     string
+
+	// inFile is whether Line is a line of the item's file.
+	inFile bool
+}
+
+// Examples lists the runnable examples of the topics, depth first, then of
+// the glossary.
+func (d Documentation) Examples() (examples []Example) {
+
+	var walk func(topic TopicRef)
+
+	walk = func(topic TopicRef) {
+
+		examples = append(examples, findExamples(topic.Item)...)
+
+		for _, child := range topic.Children {
+			walk(child)
+		}
+	}
+
+	walk(d.RootTopic)
+
+	for _, term := range d.Glossary {
+		examples = append(examples, findExamples(term.Item)...)
+	}
+
+	return
+}
+
+// findExamples returns the fenced code blocks of an item whose info string
+// names a mode, with the line of their opening fence.
+func findExamples(item Item) (examples []Example) {
+
+	lines := strings.Split(item.Content, "\n")
+
+	for i := 0; i < len(lines); i++ {
+
+		marker := codeFenceMarker(lines[i])
+
+		if marker == "" {
+			continue
+		}
+
+		info := strings.TrimSpace(strings.TrimLeft(lines[i], " ")[len(marker):])
+		info = strings.TrimSuffix(strings.TrimPrefix(info, "{"), "}")
+
+		start := i
+		code := []string{}
+
+		for i++; i < len(lines); i++ {
+
+			if closing := codeFenceMarker(lines[i]); strings.HasPrefix(closing, marker) && strings.TrimSpace(lines[i]) == closing {
+				break
+			}
+
+			code = append(code, lines[i])
+		}
+
+		fields := strings.Fields(info)
+
+		if len(fields) < 2 {
+			continue
+		}
+
+		for _, mode := range fields[1:] {
+			if mode == ExampleModeTest || mode == ExampleModeExpect {
+
+				example := Example{
+					Item:     item,
+					Line:     uint(start + 1),
+					Language: strings.ToLower(fields[0]),
+					Mode:     mode,
+					Code:     strings.Join(code, "\n"),
+				}
+
+				if start < len(item.lines) && item.lines[start] > 0 {
+					example.Line, example.inFile = item.lines[start], true
+				}
+
+				examples = append(examples, example)
+
+				break
+			}
+		}
+	}
+
+	return
+}
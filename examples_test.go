@@ -0,0 +1,32 @@
+package kman
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DocumentationShouldListItsRunnableExamples(t *testing.T) {
+
+	topic := Item{Title: "Start", FileName: "start.md", Content: "Intro\n\n```go test\nfmt.Println(1)\n```\n\n```go\nskipped\n```\n\n````{sh expect}\n$ echo ```\n```\n````"}
+	term := Item{Type: ItemTypeTerm, Title: "Node", Content: "~~~ console  expect\n$ true\n~~~"}
+
+	d := Documentation{
+		RootTopic: TopicRef{Children: []TopicRef{{Item: topic}}},
+		Glossary:  []TermRef{{Item: term}},
+	}
+
+	require.Equal(t, []Example{
+		{Item: topic, Line: 3, Language: "go", Mode: ExampleModeTest, Code: "fmt.Println(1)"},
+		{Item: topic, Line: 11, Language: "sh", Mode: ExampleModeExpect, Code: "$ echo ```\n```"},
+		{Item: term, Line: 1, Language: "console", Mode: ExampleModeExpect, Code: "$ true"},
+	}, d.Examples())
+}
+
+func Test_CodeFencesWithSeveralWordsShouldStillBeCode(t *testing.T) {
+
+	html := string(parseMarkdown("```go test\nx := 1\n```", "", DefaultMarkdownOptions()).HTML())
+
+	require.Contains(t, html, `<pre class="chroma"><code class="language-go">`)
+	require.NotContains(t, html, "test")
+}
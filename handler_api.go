@@ -0,0 +1,168 @@
+package kman
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// apiItem is an item as returned by the API, with both its markdown and the
+// HTML rendered from it.
+type apiItem struct {
+	Type     string        `json:"type"`
+	FileName string        `json:"fileName"`
+	Line     uint          `json:"line"`
+	Title    string        `json:"title"`
+	Handle   string        `json:"handle"`
+	URL      string        `json:"url"`
+	Markdown string        `json:"markdown"`
+	HTML     template.HTML `json:"html"`
+}
+
+type apiTopic struct {
+	apiItem
+	Path     string     `json:"path"`
+	Children []apiTopic `json:"children"`
+}
+
+type apiResponse struct {
+	body []byte
+	etag string
+}
+
+type handlerAPI struct {
+	responses map[string]apiResponse
+}
+
+// NewAPIHandler serves the documentation read only as JSON, under /api/:
+//
+//	/api/topics              the topic tree
+//	/api/topics/{path}       a topic and its subtopics, by handle path
+//	/api/glossary            every term
+//	/api/glossary/{handle}   a term
+//
+// Responses are encoded up front, and carry ETags hashed from their content.
+func NewAPIHandler(d Documentation) (http.Handler, error) {
+
+	h := &handlerAPI{
+		responses: make(map[string]apiResponse),
+	}
+
+	root := newAPITopic("", "/", d.RootTopic)
+
+	if err := h.addTopic(root); err != nil {
+		return nil, err
+	}
+
+	glossary := []apiItem{}
+
+	for _, term := range d.Glossary {
+
+		item := newAPIItem("/glossary#"+term.Handle, term.Item)
+		item.HTML = term.HTML()
+
+		if err := h.add("/api/glossary/"+term.Handle, item); err != nil {
+			return nil, err
+		}
+
+		glossary = append(glossary, item)
+	}
+
+	if err := h.add("/api/glossary", glossary); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+func newAPIItem(url string, item Item) apiItem {
+	return apiItem{
+		Type:     item.Type.Name(),
+		FileName: item.FileName,
+		Line:     item.Line,
+		Title:    item.Title,
+		Handle:   item.Handle,
+		URL:      url,
+		Markdown: item.Content,
+		HTML:     item.HTML(),
+	}
+}
+
+func newAPITopic(topicPath, url string, topic TopicRef) apiTopic {
+
+	t := apiTopic{
+		apiItem:  newAPIItem(url, topic.Item),
+		Path:     topicPath,
+		Children: []apiTopic{},
+	}
+
+	for _, child := range topic.Children {
+		t.Children = append(t.Children, newAPITopic(
+			path.Join(topicPath, child.Handle),
+			path.Join(url, child.Handle),
+			child,
+		))
+	}
+
+	return t
+}
+
+func (h *handlerAPI) addTopic(topic apiTopic) error {
+
+	if err := h.add(path.Join("/api/topics", topic.Path), topic); err != nil {
+		return err
+	}
+
+	for _, child := range topic.Children {
+		if err := h.addTopic(child); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *handlerAPI) add(url string, value interface{}) error {
+
+	body, err := json.Marshal(value)
+
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(body)
+
+	h.responses[url] = apiResponse{
+		body: body,
+		etag: `"` + hex.EncodeToString(sum[:8]) + `"`,
+	}
+
+	return nil
+}
+
+func (h *handlerAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response, found := h.responses[strings.TrimSuffix(path.Clean(r.URL.Path), "/")]
+
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", response.etag)
+
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(response.body))
+}
@@ -0,0 +1,108 @@
+package kman
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newValidAPIHandler(t *testing.T) http.Handler {
+
+	handler, err := NewAPIHandler(newValidDocumentation(t))
+	require.Nil(t, err)
+
+	return handler
+}
+
+func Test_AnAPIHandlerShouldServeTheTopicTree(t *testing.T) {
+
+	handler := newValidAPIHandler(t)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/topics", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var root apiTopic
+	require.Nil(t, json.Unmarshal(w.Body.Bytes(), &root))
+
+	require.Equal(t, "k-man: intuitive documentation parser and presenter", root.Title)
+	require.Len(t, root.Children, 1)
+	require.Equal(t, "usage/advanced", root.Children[0].Children[0].Path)
+	require.Equal(t, "/usage/advanced", root.Children[0].Children[0].URL)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/topics/usage/advanced", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var topic apiTopic
+	require.Nil(t, json.Unmarshal(w.Body.Bytes(), &topic))
+
+	require.Equal(t, "topic", topic.Type)
+	require.Equal(t, "This lives under 'usage'", topic.Markdown)
+	require.Contains(t, string(topic.HTML), "<p>This lives under")
+}
+
+func Test_AnAPIHandlerShouldServeTheGlossary(t *testing.T) {
+
+	handler := newValidAPIHandler(t)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/glossary", nil))
+
+	var glossary []apiItem
+	require.Nil(t, json.Unmarshal(w.Body.Bytes(), &glossary))
+	require.Len(t, glossary, 2)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/glossary/example", nil))
+
+	var term apiItem
+	require.Nil(t, json.Unmarshal(w.Body.Bytes(), &term))
+
+	require.Equal(t, "term", term.Type)
+	require.Equal(t, "/glossary#example", term.URL)
+	require.Equal(t, "An example term, parsed from markdown", term.Markdown)
+}
+
+func Test_AnAPIHandlerShouldAnswerConditionalRequests(t *testing.T) {
+
+	handler := newValidAPIHandler(t)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/glossary/example", nil))
+
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	r := httptest.NewRequest("GET", "/api/glossary/example", nil)
+	r.Header.Set("If-None-Match", etag)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusNotModified, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/glossary/another_example", nil))
+
+	require.NotEqual(t, etag, w.Header().Get("ETag"))
+}
+
+func Test_AnAPIHandlerShouldNotFindMissingItems(t *testing.T) {
+
+	handler := newValidAPIHandler(t)
+
+	for _, url := range []string{"/api/topics/missing", "/api/glossary/missing", "/api/other"} {
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+
+		require.Equal(t, http.StatusNotFound, w.Code, url)
+	}
+}
@@ -0,0 +1,169 @@
+package kman
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/spf13/afero"
+)
+
+// Paths of the live reload events and script, which servers route to the
+// live reload handler.
+const (
+	LiveReloadPath       = "/_kman/livereload"
+	LiveReloadScriptPath = "/_kman/livereload.js"
+)
+
+// LiveReloadTag loads the live reload script into a page.
+const LiveReloadTag = `<script src="` + LiveReloadScriptPath + `"></script>`
+
+// liveReloadScript reloads the page after a good build, and shows an overlay
+// with the error after a failed one.
+const liveReloadScript = `(function() {
+  var events = new EventSource("` + LiveReloadPath + `");
+
+  events.addEventListener("reload", function() { location.reload(); });
+
+  events.addEventListener("failed", function(e) {
+    var overlay = document.getElementById("kman-build-error");
+    if (!overlay) {
+      overlay = document.createElement("div");
+      overlay.id = "kman-build-error";
+      overlay.style.cssText = "position:fixed;top:0;left:0;right:0;bottom:0;z-index:10000;overflow:auto;padding:2em;" +
+        "background:rgba(20,20,20,.92);color:#f66;font-family:monospace;white-space:pre-wrap";
+      document.body.appendChild(overlay);
+    }
+    overlay.innerHTML = "<h2 style=\"color:#fff\">Build failed</h2>";
+    overlay.appendChild(document.createTextNode(e.data));
+    overlay.insertAdjacentHTML("beforeend", "<p style=\"color:#aaa\">Showing the last good build. The page reloads once the error is fixed.</p>");
+  });
+})();
+`
+
+// LiveReloadHandler serves the live reload script, and tells the browsers
+// running it about the outcome of each build, over server-sent events.
+type LiveReloadHandler interface {
+	http.Handler
+
+	// Notify tells connected browsers to reload after a good build, or shows
+	// them the error of a failed one.
+	Notify(err error)
+}
+
+type handlerLiveReload struct {
+	mu      sync.Mutex
+	err     error
+	clients map[chan error]bool
+}
+
+func NewLiveReloadHandler() LiveReloadHandler {
+	return &handlerLiveReload{
+		clients: make(map[chan error]bool),
+	}
+}
+
+func (l *handlerLiveReload) Notify(err error) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.err = err
+
+	for client := range l.clients {
+		select {
+		case client <- err:
+		default:
+		}
+	}
+}
+
+func (l *handlerLiveReload) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.URL.Path == LiveReloadScriptPath {
+		w.Header().Set("Content-Type", "application/javascript")
+		w.Write([]byte(liveReloadScript))
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	client := make(chan error, 1)
+
+	l.mu.Lock()
+	l.clients[client] = true
+
+	if l.err != nil {
+		client <- l.err
+	}
+
+	l.mu.Unlock()
+
+	defer func() {
+		l.mu.Lock()
+		delete(l.clients, client)
+		l.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	for {
+		select {
+		case err := <-client:
+
+			if err == nil {
+				fmt.Fprint(w, "event: reload\ndata: ok\n\n")
+			} else {
+				fmt.Fprint(w, "event: failed\n")
+
+				for _, line := range strings.Split(err.Error(), "\n") {
+					fmt.Fprintf(w, "data: %s\n", line)
+				}
+
+				fmt.Fprint(w, "\n")
+			}
+
+			flusher.Flush()
+
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// InjectLiveReload adds the live reload script to every page of a build.
+func InjectLiveReload(fs afero.Fs, root string) error {
+
+	return afero.Walk(fs, root, func(path string, info os.FileInfo, err error) error {
+
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".html" {
+			return err
+		}
+
+		page, err := afero.ReadFile(fs, path)
+
+		if err != nil {
+			return err
+		}
+
+		if i := bytes.LastIndex(page, []byte("</body>")); i >= 0 {
+			page = append(page[:i:i], append([]byte(LiveReloadTag), page[i:]...)...)
+		} else {
+			page = append(page, LiveReloadTag...)
+		}
+
+		return afero.WriteFile(fs, path, page, info.Mode())
+	})
+}
@@ -0,0 +1,104 @@
+package kman
+
+import (
+	"bufio"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// readEvent reads the lines of the next server-sent event.
+func readEvent(t *testing.T, events *bufio.Reader) string {
+
+	var lines []string
+
+	for {
+		line, err := events.ReadString('\n')
+		require.Nil(t, err)
+
+		if line == "\n" {
+			return strings.Join(lines, "")
+		}
+
+		lines = append(lines, line)
+	}
+}
+
+func Test_ALiveReloadHandlerShouldTellBrowsersAboutBuilds(t *testing.T) {
+
+	handler := NewLiveReloadHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + LiveReloadPath)
+	require.Nil(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	events := bufio.NewReader(response.Body)
+	require.Equal(t, ": connected\n", readEvent(t, events))
+
+	handler.Notify(errors.New("docs/a.md: topic \"A\":\ninclude x.go: file does not exist"))
+	require.Equal(t, "event: failed\ndata: docs/a.md: topic \"A\":\ndata: include x.go: file does not exist\n", readEvent(t, events))
+
+	handler.Notify(nil)
+	require.Equal(t, "event: reload\ndata: ok\n", readEvent(t, events))
+}
+
+func Test_ALiveReloadHandlerShouldTellNewBrowsersAboutAFailedBuild(t *testing.T) {
+
+	handler := NewLiveReloadHandler()
+	handler.Notify(errors.New("broken"))
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + LiveReloadPath)
+	require.Nil(t, err)
+	defer response.Body.Close()
+
+	events := bufio.NewReader(response.Body)
+	require.Equal(t, ": connected\n", readEvent(t, events))
+	require.Equal(t, "event: failed\ndata: broken\n", readEvent(t, events))
+}
+
+func Test_ALiveReloadHandlerShouldServeItsScript(t *testing.T) {
+
+	w := httptest.NewRecorder()
+	NewLiveReloadHandler().ServeHTTP(w, httptest.NewRequest("GET", LiveReloadScriptPath, nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/javascript", w.Header().Get("Content-Type"))
+
+	script, err := ioutil.ReadAll(w.Body)
+	require.Nil(t, err)
+	require.Contains(t, string(script), `new EventSource("`+LiveReloadPath+`")`)
+}
+
+func Test_LiveReloadShouldBeInjectedIntoEveryPage(t *testing.T) {
+
+	fs := newMockFilesystem(t, map[string]string{
+		"public/index.html":       "<html><body>Index</body></html>",
+		"public/usage/index.html": "<p>Usage</p>",
+		"public/css/site.css":     "body {}",
+	})
+
+	require.Nil(t, InjectLiveReload(fs, "public"))
+
+	for file, expected := range map[string]string{
+		"public/index.html":       "<html><body>Index" + LiveReloadTag + "</body></html>",
+		"public/usage/index.html": "<p>Usage</p>" + LiveReloadTag,
+		"public/css/site.css":     "body {}",
+	} {
+		content, err := afero.ReadFile(fs, file)
+		require.Nil(t, err)
+		require.Equal(t, expected, string(content), file)
+	}
+}
@@ -0,0 +1,76 @@
+package kman
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// searchLimit is the number of results returned when no limit is given.
+const searchLimit = 20
+
+type handlerSearch struct {
+	index *SearchIndex
+}
+
+type searchResponse struct {
+	Query   string         `json:"query"`
+	Total   int            `json:"total"`
+	Results []SearchResult `json:"results"`
+}
+
+// NewSearchHandler answers GET requests of the form ?q=query&limit=n with
+// the matching documents of the index, best first, as JSON.
+func NewSearchHandler(index *SearchIndex) http.Handler {
+	return &handlerSearch{
+		index: index,
+	}
+}
+
+func (h *handlerSearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+
+	if query == "" {
+		http.Error(w, "missing query parameter q", http.StatusBadRequest)
+		return
+	}
+
+	limit := searchLimit
+
+	if l := r.URL.Query().Get("limit"); l != "" {
+
+		n, err := strconv.Atoi(l)
+
+		if err != nil || n < 1 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+
+		limit = n
+	}
+
+	results := h.index.Search(query)
+	response := searchResponse{
+		Query:   query,
+		Total:   len(results),
+		Results: []SearchResult{},
+	}
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	response.Results = append(response.Results, results...)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,51 @@
+package kman
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ASearchHandlerShouldReturnResultsAsJSON(t *testing.T) {
+
+	handler := NewSearchHandler(NewSearchIndex(newValidDocumentation(t)))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/search?q=example&limit=2", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var response searchResponse
+	require.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	require.Equal(t, "example", response.Query)
+	require.Equal(t, 3, response.Total)
+	require.Len(t, response.Results, 2)
+	require.Equal(t, "Another example", response.Results[0].Title)
+}
+
+func Test_ASearchHandlerShouldRejectBadRequests(t *testing.T) {
+
+	handler := NewSearchHandler(NewSearchIndex(newValidDocumentation(t)))
+
+	for url, code := range map[string]int{
+		"/api/search":                http.StatusBadRequest,
+		"/api/search?q=a&limit=none": http.StatusBadRequest,
+		"/api/search?q=a&limit=0":    http.StatusBadRequest,
+		"/api/search?q=missing":      http.StatusOK,
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+
+		require.Equal(t, code, w.Code, url)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/search?q=example", nil))
+
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
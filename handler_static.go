@@ -0,0 +1,193 @@
+package kman
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+func init() {
+
+	// Not every system ships a mime.types file with these, and the standard
+	// library table has changed between releases.
+	for ext, typ := range map[string]string{
+		".html":  "text/html; charset=utf-8",
+		".css":   "text/css; charset=utf-8",
+		".js":    "application/javascript",
+		".json":  "application/json",
+		".xml":   "application/xml",
+		".txt":   "text/plain; charset=utf-8",
+		".svg":   "image/svg+xml",
+		".ico":   "image/x-icon",
+		".woff":  "font/woff",
+		".woff2": "font/woff2",
+	} {
+		mime.AddExtensionType(ext, typ)
+	}
+}
+
+// compressibleExtensions lists the file types worth gzipping on the fly.
+var compressibleExtensions = map[string]bool{
+	".html": true,
+	".css":  true,
+	".js":   true,
+	".json": true,
+	".xml":  true,
+	".txt":  true,
+	".svg":  true,
+}
+
+type handlerStatic struct {
+	server http.Handler
+	etags  map[string]string
+}
+
+// NewStaticHandler serves a rendered site from the root directory of fs.
+// Responses carry content hash ETags, and text files are gzipped for clients
+// that accept it. The files are expected not to change once served.
+func NewStaticHandler(fs afero.Fs, root string) (http.Handler, error) {
+
+	h := &handlerStatic{
+		server: http.FileServer(afero.NewHttpFs(fs).Dir(root)),
+		etags:  make(map[string]string),
+	}
+
+	err := afero.Walk(fs, root, func(file string, info os.FileInfo, err error) error {
+
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		data, err := afero.ReadFile(fs, file)
+
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, file)
+
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(data)
+		url := "/" + filepath.ToSlash(rel)
+
+		h.etags[url] = hex.EncodeToString(sum[:8])
+
+		if path.Base(url) == "index.html" {
+			h.etags[h.dir(path.Dir(url))] = h.etags[url]
+		}
+
+		return nil
+	})
+
+	return h, err
+}
+
+func (h *handlerStatic) dir(url string) string {
+
+	if url == "/" {
+		return url
+	}
+
+	return url + "/"
+}
+
+func (h *handlerStatic) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	url := path.Clean("/" + r.URL.Path)
+
+	if strings.HasSuffix(r.URL.Path, "/") {
+		url = h.dir(url)
+	}
+
+	etag, found := h.etags[url]
+
+	if !found {
+		h.server.ServeHTTP(w, r)
+		return
+	}
+
+	ext := path.Ext(url)
+
+	if strings.HasSuffix(url, "/") {
+		ext = ".html"
+	}
+
+	if !compressibleExtensions[ext] {
+		w.Header().Set("ETag", `"`+etag+`"`)
+		h.server.ServeHTTP(w, r)
+		return
+	}
+
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	if r.Header.Get("Range") != "" || !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("ETag", `"`+etag+`"`)
+		h.server.ServeHTTP(w, r)
+		return
+	}
+
+	w.Header().Set("ETag", `"`+etag+`-gzip"`)
+
+	gz := &gzipResponseWriter{ResponseWriter: w}
+	defer gz.Close()
+
+	h.server.ServeHTTP(gz, r)
+}
+
+// gzipResponseWriter compresses successful responses. Anything else, such as
+// redirects and 304s, is written unchanged.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	writer  *gzip.Writer
+	decided bool
+}
+
+func (g *gzipResponseWriter) WriteHeader(status int) {
+
+	if g.decided {
+		return
+	}
+
+	g.decided = true
+
+	if status == http.StatusOK {
+		g.Header().Del("Content-Length")
+		g.Header().Set("Content-Encoding", "gzip")
+		g.writer = gzip.NewWriter(g.ResponseWriter)
+	}
+
+	g.ResponseWriter.WriteHeader(status)
+}
+
+func (g *gzipResponseWriter) Write(p []byte) (int, error) {
+
+	if !g.decided {
+		g.WriteHeader(http.StatusOK)
+	}
+
+	if g.writer == nil {
+		return g.ResponseWriter.Write(p)
+	}
+
+	return g.writer.Write(p)
+}
+
+func (g *gzipResponseWriter) Close() error {
+
+	if g.writer == nil {
+		return nil
+	}
+
+	return g.writer.Close()
+}
@@ -0,0 +1,93 @@
+package kman
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newValidStaticHandler(t *testing.T) http.Handler {
+
+	fs := newMockFilesystem(t, map[string]string{
+		"public/index.html":       "<html>index</html>",
+		"public/usage/index.html": "<html>usage</html>",
+		"public/css/site.css":     "body {}",
+		"public/images/logo.png":  "\x89PNG",
+	})
+
+	handler, err := NewStaticHandler(fs, "public")
+	require.Nil(t, err)
+
+	return handler
+}
+
+func Test_AStaticHandlerShouldServeFilesWithContentTypesAndETags(t *testing.T) {
+
+	handler := newValidStaticHandler(t)
+
+	for url, contentType := range map[string]string{
+		"/":                "text/html; charset=utf-8",
+		"/usage/":          "text/html; charset=utf-8",
+		"/css/site.css":    "text/css; charset=utf-8",
+		"/images/logo.png": "image/png",
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+
+		require.Equal(t, http.StatusOK, w.Code, url)
+		require.Equal(t, contentType, w.Header().Get("Content-Type"), url)
+		require.NotEmpty(t, w.Header().Get("ETag"), url)
+
+		r := httptest.NewRequest("GET", url, nil)
+		r.Header.Set("If-None-Match", w.Header().Get("ETag"))
+
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusNotModified, w.Code, url)
+	}
+}
+
+func Test_AStaticHandlerShouldGzipTextFiles(t *testing.T) {
+
+	handler := newValidStaticHandler(t)
+
+	r := httptest.NewRequest("GET", "/usage/", nil)
+	r.Header.Set("Accept-Encoding", "gzip, deflate")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	require.Empty(t, w.Header().Get("Content-Length"))
+
+	reader, err := gzip.NewReader(w.Body)
+	require.Nil(t, err)
+
+	body, err := ioutil.ReadAll(reader)
+	require.Nil(t, err)
+	require.Equal(t, "<html>usage</html>", string(body))
+
+	r = httptest.NewRequest("GET", "/images/logo.png", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	require.Empty(t, w.Header().Get("Content-Encoding"))
+}
+
+func Test_AStaticHandlerShouldNotFindMissingFiles(t *testing.T) {
+
+	handler := newValidStaticHandler(t)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/missing/", nil))
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
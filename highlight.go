@@ -0,0 +1,213 @@
+package kman
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma"
+	chromahtml "github.com/alecthomas/chroma/formatters/html"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
+)
+
+// Default highlighting styles, for themes which don't choose their own.
+const (
+	DefaultHighlightStyle     = "github"
+	DefaultHighlightDarkStyle = "monokai"
+)
+
+// highlightCSSPath is where the styles of highlighted code are written,
+// relative to the output path, unless the theme has such a file.
+const highlightCSSPath = "css/highlight.css"
+
+// codeFence is the info string of a fenced code block: the language, then
+// options, written in braces if there are any, as in "```{go linenos hl=2,4-6}".
+type codeFence struct {
+	language    string
+	lineNumbers bool
+	start       int
+	highlight   [][2]int
+}
+
+func parseCodeFence(info string) (fence codeFence) {
+
+	fields := strings.Fields(info)
+	fence.start = 1
+
+	if len(fields) == 0 {
+		return
+	}
+
+	fence.language = strings.ToLower(fields[0])
+
+	for _, field := range fields[1:] {
+
+		name, value := field, ""
+
+		if i := strings.IndexByte(field, '='); i >= 0 {
+			name, value = field[:i], field[i+1:]
+		}
+
+		switch name {
+		case "linenos":
+			fence.lineNumbers = value == "" || value == "true"
+
+		case "start":
+			if n, err := strconv.Atoi(value); err == nil {
+				fence.start = n
+			}
+
+		case "hl":
+			fence.highlight = parseLineRanges(value)
+		}
+	}
+
+	return
+}
+
+// parseLineRanges reads lines and ranges of lines such as "2,4-6", skipping
+// any it cannot read.
+func parseLineRanges(value string) (ranges [][2]int) {
+
+	for _, part := range strings.Split(value, ",") {
+
+		from, to := part, part
+
+		if i := strings.IndexByte(part, '-'); i >= 0 {
+			from, to = part[:i], part[i+1:]
+		}
+
+		start, err := strconv.Atoi(from)
+
+		if err != nil {
+			continue
+		}
+
+		end, err := strconv.Atoi(to)
+
+		if err != nil || end < start {
+			continue
+		}
+
+		ranges = append(ranges, [2]int{start, end})
+	}
+
+	return
+}
+
+// highlightCode renders a fenced code block as HTML, with CSS classes for
+// the tokens of its language. It returns false if the language is missing or
+// unknown.
+func highlightCode(code string, info string) ([]byte, bool) {
+
+	fence := parseCodeFence(info)
+
+	if fence.language == "" {
+		return nil, false
+	}
+
+	lexer := lexers.Get(fence.language)
+
+	if lexer == nil {
+		return nil, false
+	}
+
+	tokens, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+
+	if err != nil {
+		return nil, false
+	}
+
+	formatter := chromahtml.New(
+		chromahtml.WithClasses(true),
+		chromahtml.WithPreWrapper(codePreWrapper(fence.language)),
+		chromahtml.WithLineNumbers(fence.lineNumbers),
+		chromahtml.BaseLineNumber(fence.start),
+		chromahtml.HighlightLines(fence.highlight),
+	)
+
+	var buf bytes.Buffer
+
+	if err := formatter.Format(&buf, styles.Fallback, tokens); err != nil {
+		return nil, false
+	}
+
+	return buf.Bytes(), true
+}
+
+// codePreWrapper keeps the language class blackfriday gives code blocks.
+type codePreWrapper string
+
+func (c codePreWrapper) Start(code bool, styleAttr string) string {
+	return fmt.Sprintf(`<pre%s><code class="language-%s">`, styleAttr, html.EscapeString(string(c)))
+}
+
+func (c codePreWrapper) End(code bool) string {
+	return "</code></pre>"
+}
+
+// HighlightCSS returns the CSS for highlighted code in the given style, and
+// in darkStyle, if any, when the site is shown in dark mode.
+func HighlightCSS(style, darkStyle string) ([]byte, error) {
+
+	var buf bytes.Buffer
+
+	if err := writeHighlightCSS(&buf, style, ""); err != nil {
+		return nil, err
+	}
+
+	if darkStyle == "" {
+		return buf.Bytes(), nil
+	}
+
+	if err := writeHighlightCSS(&buf, darkStyle, `:root[data-theme="dark"] `); err != nil {
+		return nil, err
+	}
+
+	buf.WriteString("@media (prefers-color-scheme: dark) {\n")
+
+	if err := writeHighlightCSS(&buf, darkStyle, `:root:not([data-theme="light"]) `); err != nil {
+		return nil, err
+	}
+
+	buf.WriteString("}\n")
+
+	return buf.Bytes(), nil
+}
+
+// writeHighlightCSS writes the rules of a style, with scope before every
+// selector.
+func writeHighlightCSS(w io.Writer, name, scope string) error {
+
+	style, ok := styles.Registry[name]
+
+	if !ok {
+		return fmt.Errorf("unknown highlight style %q", name)
+	}
+
+	var buf bytes.Buffer
+
+	if err := chromahtml.New(chromahtml.WithClasses(true), chromahtml.WithLineNumbers(true)).WriteCSS(&buf, style); err != nil {
+		return err
+	}
+
+	// Rules are written one per line, after a comment naming the token.
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+
+		if i := strings.Index(line, "*/ "); i >= 0 && scope != "" {
+			line = line[:i+3] + scope + line[i+3:]
+		}
+
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
@@ -0,0 +1,86 @@
+package kman
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ACodeFenceShouldBeParsed(t *testing.T) {
+
+	for i, c := range []struct {
+		info     string
+		expected codeFence
+	}{
+		{"", codeFence{start: 1}},
+		{"Go", codeFence{language: "go", start: 1}},
+		{"go linenos", codeFence{language: "go", lineNumbers: true, start: 1}},
+		{"go linenos=false start=10", codeFence{language: "go", start: 10}},
+		{"sh hl=2,4-6,x,9-7", codeFence{language: "sh", start: 1, highlight: [][2]int{{2, 2}, {4, 6}}}},
+	} {
+		t.Run(fmt.Sprintf("Cycle %d: %q", i, c.info), func(t *testing.T) {
+			require.Equal(t, c.expected, parseCodeFence(c.info))
+		})
+	}
+}
+
+func Test_CodeBlocksShouldBeHighlighted(t *testing.T) {
+
+	for i, c := range []struct {
+		language string
+		code     string
+		token    string
+	}{
+		{"go", "func main() {}", `<span class="kd">func</span>`},
+		{"shell", "echo $HOME", `<span class="nb">echo</span>`},
+		{"json", `{"a": 1}`, `<span class="nt">&#34;a&#34;</span>`},
+		{"yaml", "a: true", `<span class="kc">true</span>`},
+		{"solidity", "contract Token {}", `<span class="kd">contract</span>`},
+	} {
+		t.Run(fmt.Sprintf("Cycle %d: %s", i, c.language), func(t *testing.T) {
+
+			html := string(Item{Content: "```" + c.language + "\n" + c.code + "\n```\n"}.HTML())
+
+			require.Contains(t, html, `<pre class="chroma"><code class="language-`+c.language+`">`)
+			require.Contains(t, html, c.token)
+		})
+	}
+}
+
+func Test_CodeBlocksCanHaveLineNumbersAndHighlightedLines(t *testing.T) {
+
+	html := string(Item{Content: "```{go linenos start=7 hl=8}\na := 1\nb := 2\n```\n"}.HTML())
+
+	require.Contains(t, html, `<span class="ln">7</span>`)
+	require.Contains(t, html, `<span class="line hl"><span class="ln">8</span>`)
+}
+
+func Test_CodeBlocksInUnknownLanguagesShouldBePlain(t *testing.T) {
+
+	for _, info := range []string{"", "nosuchlanguage"} {
+
+		html := string(Item{Content: "```" + info + "\n<a>\n```\n"}.HTML())
+
+		require.Contains(t, html, "&lt;a&gt;")
+		require.NotContains(t, html, "chroma")
+	}
+}
+
+func Test_HighlightCSSShouldScopeTheDarkStyle(t *testing.T) {
+
+	css, err := HighlightCSS("github", "monokai")
+	require.Nil(t, err)
+
+	require.Contains(t, string(css), "*/ .chroma { ")
+	require.Contains(t, string(css), `*/ :root[data-theme="dark"] .chroma { `)
+	require.Contains(t, string(css), `@media (prefers-color-scheme: dark)`)
+
+	css, err = HighlightCSS("github", "")
+	require.Nil(t, err)
+	require.False(t, strings.Contains(string(css), "dark"))
+
+	_, err = HighlightCSS("nosuchstyle", "")
+	require.NotNil(t, err)
+}
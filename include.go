@@ -0,0 +1,274 @@
+package kman
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+var (
+	// includeDirective is {{< include "path" >}}, where the path may end in
+	// #region, followed by options such as lines="14-20". Written as
+	// {{</* include "path" */>}}, it is shown as the directive instead.
+	includeDirective = regexp.MustCompile(`\{\{<(/\*)?\s*include\s+"([^"]+)"((?:\s+\w+="[^"]*")*)\s*(\*/)?>\}\}`)
+	includeOption    = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+	// regionMarker is a comment such as "// #region setup" or
+	// "# region: setup", or the matching "#endregion" or "endregion:". Only
+	// a bare "#region" needs no comment leader, so that content such as the
+	// YAML key "region: eu-west-1" isn't taken for a marker.
+	regionMarker = regexp.MustCompile(`^\s*(?:(?://|#|--|;|<!--|/\*)\s*(?:#(end)?region|(end)?region:)|#(end)?region)(?:\s+|:|$)\s*([\w.-]*)`)
+)
+
+// includeSnippets replaces the include directives in the content of items by
+// the part of the file they name. Paths are relative to the root, or to the
+// file of the item if they start with ./ or ../.
+func (o AssemblerOptions) includeSnippets(fs afero.Fs, items []Item) error {
+
+	for i, item := range items {
+
+		if !strings.Contains(item.Content, "{{<") {
+			continue
+		}
+
+		err := replaceContent(&items[i], includeDirective, func(directive []string) (string, error) {
+
+			if directive[1] != "" || directive[4] != "" {
+				return escapedInclude(directive[0]), nil
+			}
+
+			snippet, file, err := o.include(fs, item, directive)
+
+			if err == nil {
+				items[i].includes = append(items[i].includes, file)
+			}
+
+			return snippet, err
+		})
+
+		if err != nil {
+			return fmt.Errorf("%s: %s %q: %s", item.FileName, item.Type.Name(), item.Title, err)
+		}
+	}
+
+	return nil
+}
+
+// include returns the snippet a directive names, and the file it is from.
+func (o AssemblerOptions) include(fs afero.Fs, item Item, directive []string) (string, string, error) {
+
+	path, region := directive[2], ""
+
+	if i := strings.IndexByte(path, '#'); i >= 0 {
+		path, region = path[:i], path[i+1:]
+	}
+
+	var lines string
+
+	for _, option := range includeOption.FindAllStringSubmatch(directive[3], -1) {
+		switch option[1] {
+		case "lines":
+			lines = option[2]
+
+		case "region":
+			region = option[2]
+
+		default:
+			return "", "", fmt.Errorf("include %s: unknown option %q", directive[2], option[1])
+		}
+	}
+
+	file := filepath.Join(o.root(), filepath.FromSlash(path))
+
+	if strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../") {
+		file = filepath.Join(filepath.Dir(item.FileName), filepath.FromSlash(path))
+	}
+
+	data, err := afero.ReadFile(fs, file)
+
+	if err != nil {
+		return "", "", fmt.Errorf("include %s: %s", directive[2], err)
+	}
+
+	snippet := strings.Split(strings.TrimRight(strings.Replace(string(data), "\r\n", "\n", -1), "\n"), "\n")
+
+	if region != "" {
+		if snippet, err = includeRegion(snippet, region); err != nil {
+			return "", "", fmt.Errorf("include %s: %s", directive[2], err)
+		}
+	}
+
+	if lines != "" {
+		if snippet, err = includeLines(snippet, lines); err != nil {
+			return "", "", fmt.Errorf("include %s: %s", directive[2], err)
+		}
+	}
+
+	return strings.Join(dedentLines(withoutRegionMarkers(snippet)), "\n"), file, nil
+}
+
+// escapedInclude returns an include directive written as {{</* include */>}}
+// as it would be written to run.
+func escapedInclude(directive string) string {
+
+	directive = strings.TrimSuffix(strings.TrimPrefix(directive, "{{<"), ">}}")
+
+	return "{{<" + strings.TrimSuffix(strings.TrimPrefix(directive, "/*"), "*/") + ">}}"
+}
+
+// Includes lists the files the topics and terms of the documentation include
+// snippets from, so that they can be watched along with the sources.
+func (d Documentation) Includes() (files []string) {
+
+	found := make(map[string]bool)
+
+	add := func(item Item) {
+		for _, file := range item.includes {
+			if !found[file] {
+				found[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+
+	var walk func(topic TopicRef)
+
+	walk = func(topic TopicRef) {
+
+		add(topic.Item)
+
+		for _, child := range topic.Children {
+			walk(child)
+		}
+	}
+
+	walk(d.RootTopic)
+
+	for _, term := range d.Glossary {
+		add(term.Item)
+	}
+
+	sort.Strings(files)
+
+	return
+}
+
+// includeRegion returns the lines between the markers of a named region.
+// Regions nested in it may close with unnamed markers.
+func includeRegion(lines []string, name string) ([]string, error) {
+
+	start, depth := -1, 0
+
+	for i, line := range lines {
+
+		m := regionMarker.FindStringSubmatch(line)
+
+		if m == nil {
+			continue
+		}
+
+		end, marker := m[1] != "" || m[2] != "" || m[3] != "", m[4]
+
+		switch {
+		case start < 0:
+			if !end && marker == name {
+				start = i + 1
+			}
+
+		case !end:
+			depth++
+
+		case marker == name || marker == "" && depth == 0:
+			return lines[start:i], nil
+
+		case depth > 0:
+			depth--
+		}
+	}
+
+	if start >= 0 {
+		return nil, fmt.Errorf("region %q is not closed", name)
+	}
+
+	return nil, fmt.Errorf("region %q not found", name)
+}
+
+// includeLines returns a range of lines, such as "14-20", "14" or "14-",
+// counted from 1.
+func includeLines(lines []string, spec string) ([]string, error) {
+
+	from, to := spec, spec
+
+	if i := strings.IndexByte(spec, '-'); i >= 0 {
+		from, to = spec[:i], spec[i+1:]
+	}
+
+	start, err := strconv.Atoi(from)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid lines %q", spec)
+	}
+
+	end := len(lines)
+
+	if to != "" {
+		if end, err = strconv.Atoi(to); err != nil {
+			return nil, fmt.Errorf("invalid lines %q", spec)
+		}
+	}
+
+	if start < 1 || end < start || end > len(lines) {
+		return nil, fmt.Errorf("lines %q out of range, the file has %d", spec, len(lines))
+	}
+
+	return lines[start-1 : end], nil
+}
+
+// withoutRegionMarkers drops the markers of regions nested in a snippet.
+func withoutRegionMarkers(lines []string) (output []string) {
+
+	for _, line := range lines {
+		if !regionMarker.MatchString(line) {
+			output = append(output, line)
+		}
+	}
+
+	return
+}
+
+// dedentLines removes the indentation common to every non-blank line.
+func dedentLines(lines []string) []string {
+
+	indent, found := "", false
+
+	for _, line := range lines {
+
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		prefix := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+
+		if !found {
+			indent, found = prefix, true
+			continue
+		}
+
+		for !strings.HasPrefix(prefix, indent) {
+			indent = indent[:len(indent)-1]
+		}
+	}
+
+	output := make([]string, len(lines))
+
+	for i, line := range lines {
+		output[i] = strings.TrimPrefix(line, indent)
+	}
+
+	return output
+}
@@ -0,0 +1,182 @@
+package kman
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const includeSource = `package main
+
+import "fmt"
+
+func main() {
+	// #region greet
+	name := "world"
+	// #region print
+	fmt.Println("hello", name)
+	// #endregion
+	// #endregion greet
+}
+`
+
+const includeScript = `#!/bin/sh
+# region: setup
+  mkdir -p build
+    cd build
+# endregion:
+make
+`
+
+const includeConfig = `# region: cluster
+cluster:
+  region: eu-west-1
+  name: main
+# endregion:
+region: us-east-1
+`
+
+func Test_IncludeDirectivesShouldPullInSnippets(t *testing.T) {
+
+	for i, c := range []struct {
+		description string
+		content     string
+		expected    string
+		err         string
+	}{
+		{
+			"Whole file",
+			`{{< include "docs/run.sh" >}}`,
+			"#!/bin/sh\n  mkdir -p build\n    cd build\nmake",
+			"",
+		},
+		{
+			"Lines, without region markers",
+			`{{< include "cmd/main.go" lines="5-7" >}}`,
+			"func main() {\n\tname := \"world\"",
+			"",
+		},
+		{
+			"Lines to the end",
+			`{{< include "cmd/main.go" lines="12-" >}}`,
+			"}",
+			"",
+		},
+		{
+			"Region with nested markers",
+			`{{< include "cmd/main.go#greet" >}}`,
+			"name := \"world\"\nfmt.Println(\"hello\", name)",
+			"",
+		},
+		{
+			"Nested region",
+			`{{< include "cmd/main.go" region="print" >}}`,
+			"fmt.Println(\"hello\", name)",
+			"",
+		},
+		{
+			"Region in a script, relative to the topic",
+			"```sh\n{{< include \"./run.sh#setup\" >}}\n```",
+			"```sh\nmkdir -p build\n  cd build\n```",
+			"",
+		},
+		{
+			"Region holding a region key",
+			`{{< include "deploy/config.yaml#cluster" >}}`,
+			"cluster:\n  region: eu-west-1\n  name: main",
+			"",
+		},
+		{
+			"Region keys outside a region",
+			`{{< include "deploy/config.yaml" lines="2-" >}}`,
+			"cluster:\n  region: eu-west-1\n  name: main\nregion: us-east-1",
+			"",
+		},
+		{
+			"Escaped directives",
+			"```\n{{</* include \"cmd/gone.go#setup\" lines=\"1-2\" */>}}\n```\n{{</*include \"cmd/main.go\"*/>}}",
+			"```\n{{< include \"cmd/gone.go#setup\" lines=\"1-2\" >}}\n```\n{{<include \"cmd/main.go\">}}",
+			"",
+		},
+		{
+			"Missing file",
+			`{{< include "cmd/gone.go" >}}`,
+			"",
+			`docs/topic.md: topic "Usage": include cmd/gone.go: open cmd/gone.go: file does not exist`,
+		},
+		{
+			"Missing region",
+			`{{< include "cmd/main.go#gone" >}}`,
+			"",
+			`docs/topic.md: topic "Usage": include cmd/main.go#gone: region "gone" not found`,
+		},
+		{
+			"Lines out of range",
+			`{{< include "cmd/main.go" lines="10-20" >}}`,
+			"",
+			`docs/topic.md: topic "Usage": include cmd/main.go: lines "10-20" out of range, the file has 12`,
+		},
+		{
+			"Unknown option",
+			`{{< include "cmd/main.go" line="1" >}}`,
+			"",
+			`docs/topic.md: topic "Usage": include cmd/main.go: unknown option "line"`,
+		},
+	} {
+		t.Run(fmt.Sprintf("Cycle %d: %s", i, c.description), func(t *testing.T) {
+
+			fs := newMockFilesystem(t, map[string]string{
+				"cmd/main.go":        includeSource,
+				"docs/run.sh":        includeScript,
+				"deploy/config.yaml": includeConfig,
+			})
+
+			items := []Item{{Type: ItemTypeTopic, Title: "Usage", FileName: "docs/topic.md", Content: c.content}}
+			err := AssemblerOptions{}.includeSnippets(fs, items)
+
+			if c.err != "" {
+				require.EqualError(t, err, c.err)
+				return
+			}
+
+			require.Nil(t, err)
+			require.Equal(t, c.expected, items[0].Content)
+		})
+	}
+}
+
+func Test_AnAssemblerShouldFailWhenAnIncludedFileIsMissing(t *testing.T) {
+
+	fs := newMockFilesystem(t, map[string]string{
+		"docs/topic.md": "Topic: Usage\n{{< include \"main.go#run\" >}}",
+		"docs/main.go":  "package main\n\n// #region run\nfunc main() {}\n// #endregion\n",
+	})
+
+	assembler := NewMarkdownAssemblerWithOptions(fs, AssemblerOptions{Root: "docs"})
+	items, err := assembler.Assemble()
+
+	require.Nil(t, err)
+	require.Equal(t, "func main() {}", items[0].Content)
+
+	require.Nil(t, fs.Remove("docs/main.go"))
+
+	_, err = assembler.Assemble()
+
+	require.Error(t, err)
+}
+
+func Test_DocumentationShouldListTheFilesItIncludes(t *testing.T) {
+
+	fs := newMockFilesystem(t, map[string]string{
+		"docs/topics.md": "Topic: Usage\n{{< include \"../cmd/main.go\" >}}\n\nTopic: Advanced\n{{< include \"run.sh\" >}}\n{{< include \"./run.sh\" >}}",
+		"docs/terms.md":  "Term: Node\n{{< include \"../cmd/main.go\" lines=\"1\" >}}",
+		"docs/run.sh":    includeScript,
+		"cmd/main.go":    includeSource,
+	})
+
+	d, err := NewDefaultDocumenter(NewDefaultSorter(), NewMarkdownAssemblerWithOptions(fs, AssemblerOptions{Root: "docs"})).Document()
+	require.Nil(t, err)
+
+	require.Equal(t, []string{"cmd/main.go", "docs/run.sh"}, d.Includes())
+}
@@ -6,6 +6,12 @@ const (
 	topicToken  = "topic:"
 	termToken   = "term:"
 	handleToken = "handle:"
+
+	// Read only above the content of an item, so that they can't be taken
+	// for text.
+	draftToken    = "draft:"
+	audienceToken = "audience:"
+	tagsToken     = "tags:"
 )
 
 type itemiserString struct {
@@ -25,24 +31,64 @@ func (s *itemiserString) Itemise(items *[]Item) error {
 	lines := strings.Split(s.input, "\n")
 
 	title, handle, content, typ := "", "", []string{}, ItemTypeTopic
+	draft, audience, tags, started := false, []string(nil), []string(nil), false
+	start, contentLines := uint(0), []uint{}
+	fence := ""
 
 	reset := func() {
 		title, handle, content, typ = "", "", []string{}, ItemTypeTopic
+		draft, audience, tags, started = false, nil, nil, false
+		start, contentLines = 0, []uint{}
 	}
 
 	addItem := func(typ ItemType) {
+
+		first, last := 0, len(content)
+
+		for first < last && content[first] == "" {
+			first++
+		}
+
+		for last > first && content[last-1] == "" {
+			last--
+		}
+
 		*items = append(*items, Item{
 			Type:     typ,
 			FileName: s.path,
+			Line:     start,
 			Title:    title,
 			Handle:   handle,
-			Content:  strings.Trim(strings.Join(content, "\n"), "\n"),
+			Content:  strings.Join(content[first:last], "\n"),
+			Draft:    draft,
+			Audience: audience,
+			Tags:     tags,
+			lines:    contentLines[first:last],
 		})
 	}
 
-	for _, line := range lines {
+	for n, line := range lines {
 		line = strings.TrimSpace(line)
 
+		// Lines of fenced code blocks are content, even when they look like
+		// the start of an item, as in examples of how to write one.
+		if marker := codeFenceMarker(line); marker != "" {
+			switch {
+			case fence == "":
+				fence = marker
+
+			case strings.HasPrefix(marker, fence) && line == marker:
+				fence = ""
+			}
+		} else if fence != "" {
+			if title != "" {
+				content = append(content, line)
+				contentLines = append(contentLines, uint(n+1))
+			}
+
+			continue
+		}
+
 		if strings.HasPrefix(strings.ToLower(line), topicToken) {
 
 			if title != "" && len(lines) > 0 {
@@ -53,6 +99,7 @@ func (s *itemiserString) Itemise(items *[]Item) error {
 			title = strings.TrimSpace(line[len(topicToken):])
 			handle = s.handlise(title)
 			typ = ItemTypeTopic
+			start = uint(n + 1)
 
 		} else if strings.HasPrefix(strings.ToLower(line), termToken) {
 
@@ -64,11 +111,20 @@ func (s *itemiserString) Itemise(items *[]Item) error {
 			title = strings.TrimSpace(line[len(topicToken):])
 			handle = s.handlise(title)
 			typ = ItemTypeTerm
+			start = uint(n + 1)
 
 		} else if strings.HasPrefix(strings.ToLower(line), handleToken) {
 			handle = strings.TrimSpace(line[len(handleToken):])
+		} else if title != "" && !started && strings.HasPrefix(strings.ToLower(line), draftToken) {
+			draft = s.flag(line[len(draftToken):])
+		} else if title != "" && !started && strings.HasPrefix(strings.ToLower(line), audienceToken) {
+			audience = s.list(line[len(audienceToken):])
+		} else if title != "" && !started && strings.HasPrefix(strings.ToLower(line), tagsToken) {
+			tags = s.list(line[len(tagsToken):])
 		} else if title != "" {
 			content = append(content, line)
+			contentLines = append(contentLines, uint(n+1))
+			started = started || line != ""
 		}
 	}
 
@@ -79,6 +135,29 @@ func (s *itemiserString) Itemise(items *[]Item) error {
 	return nil
 }
 
+// flag reads a directive which is on unless it says otherwise.
+func (s *itemiserString) flag(value string) bool {
+
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "false", "no", "off", "0":
+		return false
+	}
+
+	return true
+}
+
+// list reads a directive holding a list separated by commas.
+func (s *itemiserString) list(value string) (list []string) {
+
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+
+	return
+}
+
 func (g *itemiserString) handlise(input string) (output string) {
 
 	input = strings.TrimSpace(input)
@@ -128,6 +128,42 @@ func Test_AValidItemiserStringShouldFindTopicsAndTerms(t *testing.T) {
 
 	Term: test 2
 	Line 2
+`,
+			err: false,
+		},
+		{
+			description: "Directives",
+			input: `
+	Topic: test A
+	Draft: yes
+	Audience: operator, partner
+	Tags: nodes,staking
+	Line A
+	Tags: not a directive
+
+	Term: test 1
+	Draft: false
+	Line 1
+`,
+			err: false,
+		},
+		{
+			description: "Fenced items",
+			input: `
+	Topic: test A
+	Write a topic as:
+	~~~
+	Topic: Not a topic
+	Handle: not_a_handle
+	~~~
+	Line A
+
+	` + "````" + `
+	Term: Not a term
+	` + "```" + `
+	Topic: Not a topic either
+	` + "````" + `
+	Line B
 `,
 			err: false,
 		},
@@ -0,0 +1,172 @@
+package kman
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// lineWriter rewrites the content of an item piece by piece, keeping track of
+// the line in the item's file each line of the new content comes from.
+type lineWriter struct {
+	source  string
+	starts  []int
+	lines   []uint
+	output  strings.Builder
+	written []uint
+}
+
+func newLineWriter(item Item) *lineWriter {
+
+	w := &lineWriter{source: item.Content, starts: []int{0}, lines: item.lines}
+
+	for i := 0; i < len(item.Content); i++ {
+		if item.Content[i] == '\n' {
+			w.starts = append(w.starts, i+1)
+		}
+	}
+
+	return w
+}
+
+// line returns the line in the file of the source at offset, or 0 if the
+// item doesn't know where its content comes from.
+func (w *lineWriter) line(offset int) uint {
+
+	i := sort.Search(len(w.starts), func(i int) bool { return w.starts[i] > offset }) - 1
+
+	if i < 0 || i >= len(w.lines) {
+		return 0
+	}
+
+	return w.lines[i]
+}
+
+// copy writes the source between two offsets.
+func (w *lineWriter) copy(from, to int) {
+
+	if from < to && len(w.written) == 0 {
+		w.written = append(w.written, w.line(from))
+	}
+
+	for i := from; i < to; i++ {
+		if w.source[i] == '\n' {
+			w.written = append(w.written, w.line(i+1))
+		}
+	}
+
+	w.output.WriteString(w.source[from:to])
+}
+
+// insert writes text in place of the source at offset, so that all its
+// lines come from the line at offset.
+func (w *lineWriter) insert(text string, offset int) {
+
+	if text != "" && len(w.written) == 0 {
+		w.written = append(w.written, w.line(offset))
+	}
+
+	for i := strings.Count(text, "\n"); i > 0; i-- {
+		w.written = append(w.written, w.line(offset))
+	}
+
+	w.output.WriteString(text)
+}
+
+// finish copies the rest of the source from offset, and stores the new
+// content in item.
+func (w *lineWriter) finish(item *Item, offset int) {
+
+	w.copy(offset, len(w.source))
+
+	item.Content = w.output.String()
+
+	if w.lines != nil {
+		item.lines = w.written
+	}
+}
+
+// replaceContent replaces every match of re in the content of item by what
+// replace returns for its submatches, stopping at the first error.
+func replaceContent(item *Item, re *regexp.Regexp, replace func(match []string) (string, error)) error {
+	return replaceContentExcept(item, re, nil, replace)
+}
+
+// replaceContentExcept is replaceContent, leaving the matches starting in
+// any of the ranges of offsets skipped alone.
+func replaceContentExcept(item *Item, re *regexp.Regexp, skipped [][2]int, replace func(match []string) (string, error)) error {
+
+	w, last := newLineWriter(*item), 0
+
+	for _, m := range re.FindAllStringSubmatchIndex(item.Content, -1) {
+
+		if inRanges(m[0], skipped) {
+			continue
+		}
+
+		match := make([]string, len(m)/2)
+
+		for i := range match {
+			if m[2*i] >= 0 {
+				match[i] = item.Content[m[2*i]:m[2*i+1]]
+			}
+		}
+
+		text, err := replace(match)
+
+		if err != nil {
+			return err
+		}
+
+		w.copy(last, m[0])
+		w.insert(text, m[0])
+		last = m[1]
+	}
+
+	w.finish(item, last)
+
+	return nil
+}
+
+func inRanges(offset int, ranges [][2]int) bool {
+
+	for _, r := range ranges {
+		if offset >= r[0] && offset < r[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// offsetLines moves the lines of items read from a part of a file, which
+// starts at line, to their lines in the file.
+func offsetLines(items []Item, line uint) {
+
+	for i := range items {
+
+		items[i].Line += line - 1
+
+		lines := make([]uint, len(items[i].lines))
+
+		for j, l := range items[i].lines {
+			lines[j] = l + line - 1
+		}
+
+		items[i].lines = lines
+	}
+}
+
+// cachedItem is an item as kept in the cache, with the lines of its content.
+type cachedItem struct {
+	Item
+	Lines []uint
+}
+
+func (c cachedItem) item() Item {
+
+	item := c.Item
+	item.lines = c.Lines
+
+	return item
+}
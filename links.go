@@ -0,0 +1,93 @@
+package kman
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// linkAttributes matches the root-relative URLs of links, images, scripts
+// and forms in rendered HTML.
+var linkAttributes = regexp.MustCompile(`(\s(?:href|src|action)=")(/[^"]*)"`)
+
+// linker rewrites the root-relative links of rendered pages, for sites
+// hosted under a sub-path or opened from disk. Templates and content link
+// to pages by their path within the site, such as "/usage/advanced".
+type linker struct {
+	base     string
+	relative bool
+}
+
+// newLinker prefixes links with the path of baseURL, or makes them relative
+// to each page, pointing at index.html files so that pages work from file://
+// URLs.
+func newLinker(baseURL string, relative bool) (linker, error) {
+
+	l := linker{
+		relative: relative,
+	}
+
+	if baseURL == "" {
+		return l, nil
+	}
+
+	u, err := url.Parse(baseURL)
+
+	if err != nil {
+		return l, fmt.Errorf("invalid base URL %q: %s", baseURL, err)
+	}
+
+	l.base = strings.TrimSuffix(u.Path, "/")
+
+	return l, nil
+}
+
+// root returns what site paths, without their leading slash, are appended
+// to, from the page at the given path.
+func (l linker) root(page string) string {
+
+	if !l.relative {
+		return l.base + "/"
+	}
+
+	return strings.Repeat("../", len(strings.FieldsFunc(page, func(r rune) bool { return r == '/' })))
+}
+
+// link returns the URL of a site path from the page at the given path.
+func (l linker) link(page, target string) string {
+
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") {
+		return target
+	}
+
+	suffix := ""
+
+	if i := strings.IndexAny(target, "?#"); i >= 0 {
+		target, suffix = target[:i], target[i:]
+	}
+
+	// Pages are directories, which browsers do not resolve to their index
+	// on disk.
+	if l.relative && path.Ext(target) == "" {
+		target = strings.TrimSuffix(target, "/") + "/index.html"
+	}
+
+	return l.root(page) + strings.TrimPrefix(target, "/") + suffix
+}
+
+// rewrite rewrites every root-relative link of a page.
+func (l linker) rewrite(page string, html []byte) []byte {
+
+	if l.base == "" && !l.relative {
+		return html
+	}
+
+	return linkAttributes.ReplaceAllFunc(html, func(match []byte) []byte {
+
+		parts := linkAttributes.FindSubmatch(match)
+
+		return []byte(string(parts[1]) + l.link(page, string(parts[2])) + `"`)
+	})
+}
@@ -0,0 +1,52 @@
+package kman
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ALinkerShouldRewriteRootRelativeLinks(t *testing.T) {
+
+	html := `<a href="/usage/advanced#top">A</a><link href="/css/site.css"><img src="/"><a href="//cdn.example.org/x.js"><a href="https://example.org/">E</a><a href="#top">T</a>`
+
+	for i, c := range []struct {
+		description string
+		baseURL     string
+		relative    bool
+		page        string
+		expected    string
+	}{
+		{
+			"Unchanged by default", "", false, "/usage",
+			html,
+		},
+		{
+			"Under a base URL", "https://example.github.io/project/", false, "/usage",
+			`<a href="/project/usage/advanced#top">A</a><link href="/project/css/site.css"><img src="/project/"><a href="//cdn.example.org/x.js"><a href="https://example.org/">E</a><a href="#top">T</a>`,
+		},
+		{
+			"Relative from the root page", "", true, "/",
+			`<a href="usage/advanced/index.html#top">A</a><link href="css/site.css"><img src="index.html"><a href="//cdn.example.org/x.js"><a href="https://example.org/">E</a><a href="#top">T</a>`,
+		},
+		{
+			"Relative from a nested page", "https://example.github.io/project/", true, "/usage/advanced",
+			`<a href="../../usage/advanced/index.html#top">A</a><link href="../../css/site.css"><img src="../../index.html"><a href="//cdn.example.org/x.js"><a href="https://example.org/">E</a><a href="#top">T</a>`,
+		},
+	} {
+		t.Run(fmt.Sprintf("Cycle %d: %s", i, c.description), func(t *testing.T) {
+
+			l, err := newLinker(c.baseURL, c.relative)
+			require.Nil(t, err)
+
+			require.Equal(t, c.expected, string(l.rewrite(c.page, []byte(html))))
+		})
+	}
+}
+
+func Test_ALinkerShouldRejectInvalidBaseURLs(t *testing.T) {
+
+	_, err := newLinker("http://[::1", false)
+	require.NotNil(t, err)
+}
@@ -0,0 +1,308 @@
+package kman
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"unicode"
+
+	"github.com/russross/blackfriday"
+)
+
+// Heading is an entry of a table of contents, holding the headings below it.
+type Heading struct {
+	Level    int
+	Title    string
+	ID       string
+	Children []Heading
+}
+
+// MarkdownOptions turns extensions of the markdown syntax on or off.
+type MarkdownOptions struct {
+	Tables          bool `yaml:"tables" toml:"tables" json:"tables"`
+	Footnotes       bool `yaml:"footnotes" toml:"footnotes" json:"footnotes"`
+	DefinitionLists bool `yaml:"definitionLists" toml:"definitionLists" json:"definitionLists"`
+	TaskLists       bool `yaml:"taskLists" toml:"taskLists" json:"taskLists"`
+	Strikethrough   bool `yaml:"strikethrough" toml:"strikethrough" json:"strikethrough"`
+	Autolink        bool `yaml:"autolink" toml:"autolink" json:"autolink"`
+
+	// Smartypants turns quotes, dashes and fractions into their typographic
+	// forms.
+	Smartypants bool `yaml:"smartypants" toml:"smartypants" json:"smartypants"`
+
+	// Admonitions renders quotes starting with a label, such as
+	// "> **Warning**", and "!!! warning" blocks as callouts.
+	Admonitions bool `yaml:"admonitions" toml:"admonitions" json:"admonitions"`
+}
+
+// DefaultMarkdownOptions are blackfriday's common extensions, and
+// admonitions.
+func DefaultMarkdownOptions() MarkdownOptions {
+	return MarkdownOptions{
+		Tables:          true,
+		DefinitionLists: true,
+		Strikethrough:   true,
+		Autolink:        true,
+		Smartypants:     true,
+		Admonitions:     true,
+	}
+}
+
+func (o MarkdownOptions) extensions() blackfriday.Extensions {
+
+	extensions := blackfriday.NoIntraEmphasis | blackfriday.FencedCode | blackfriday.SpaceHeadings |
+		blackfriday.HeadingIDs | blackfriday.BackslashLineBreak
+
+	for _, option := range []struct {
+		on        bool
+		extension blackfriday.Extensions
+	}{
+		{o.Tables, blackfriday.Tables},
+		{o.Footnotes, blackfriday.Footnotes},
+		{o.DefinitionLists, blackfriday.DefinitionLists},
+		{o.Strikethrough, blackfriday.Strikethrough},
+		{o.Autolink, blackfriday.Autolink},
+	} {
+		if option.on {
+			extensions |= option.extension
+		}
+	}
+
+	return extensions
+}
+
+func (o MarkdownOptions) flags() blackfriday.HTMLFlags {
+
+	if o.Smartypants {
+		return blackfriday.CommonHTMLFlags
+	}
+
+	return blackfriday.UseXHTML
+}
+
+// markdown is a parsed document, whose headings have IDs unique within it.
+type markdown struct {
+	ast      *blackfriday.Node
+	headings []Heading
+	prefix   string
+	options  MarkdownOptions
+}
+
+// bracedCodeFences puts the info strings of fenced code blocks in braces if
+// they have more than one word, as in "```go test", which blackfriday would
+// otherwise not take for a fence.
+func bracedCodeFences(content string) string {
+
+	if !strings.Contains(content, "```") && !strings.Contains(content, "~~~") {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	fence := ""
+
+	for i, line := range lines {
+
+		marker := codeFenceMarker(line)
+
+		switch {
+		case marker == "":
+			continue
+
+		case fence != "":
+			if strings.HasPrefix(marker, fence) && strings.TrimSpace(line) == marker {
+				fence = ""
+			}
+
+			continue
+		}
+
+		fence = marker
+		indent := line[:strings.Index(line, marker)]
+		info := strings.TrimSpace(line[len(indent)+len(marker):])
+
+		if strings.ContainsAny(info, " \t") && !strings.HasPrefix(info, "{") {
+			lines[i] = indent + marker + "{" + info + "}"
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// parseMarkdown parses content, giving every heading an ID made from its
+// text and prefix. Headings with an explicit {#id} keep it.
+func parseMarkdown(content, prefix string, options MarkdownOptions) markdown {
+
+	content = bracedCodeFences(content)
+
+	if options.Admonitions {
+		content = admonitionBlocks(content)
+	}
+
+	parser := blackfriday.New(blackfriday.WithExtensions(options.extensions()))
+
+	md := markdown{
+		ast:     parser.Parse([]byte(content)),
+		prefix:  prefix,
+		options: options,
+	}
+
+	if options.Admonitions {
+		admonitions(md.ast)
+	}
+
+	if options.TaskLists {
+		taskLists(md.ast)
+	}
+
+	ids := make(map[string]bool)
+
+	md.ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+
+		if !entering || node.Type != blackfriday.Heading || node.IsTitleblock {
+			return blackfriday.GoToNext
+		}
+
+		title := markdownNodeText(node)
+		id := node.HeadingID
+
+		if id == "" {
+			id = prefix + headingID(title)
+		}
+
+		unique := id
+
+		for i := 1; ids[unique]; i++ {
+			unique = fmt.Sprintf("%s-%d", id, i)
+		}
+
+		ids[unique] = true
+		node.HeadingID = unique
+
+		md.headings = append(md.headings, Heading{
+			Level: node.Level,
+			Title: title,
+			ID:    unique,
+		})
+
+		return blackfriday.SkipChildren
+	})
+
+	return md
+}
+
+// HTML renders the document, highlighting code blocks in known languages.
+func (md markdown) HTML() template.HTML {
+	return md.render(true)
+}
+
+func (md markdown) render(highlight bool) template.HTML {
+
+	renderer := blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{
+		Flags:                md.options.flags(),
+		FootnoteAnchorPrefix: md.prefix,
+	})
+
+	var buf bytes.Buffer
+
+	renderer.RenderHeader(&buf, md.ast)
+
+	md.ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+
+		if highlight && node.Type == blackfriday.CodeBlock {
+			if code, ok := highlightCode(string(node.Literal), string(node.Info)); ok {
+
+				// Spaced as blackfriday spaces code blocks.
+				if buf.Len() > 0 {
+					buf.WriteByte('\n')
+				}
+
+				buf.Write(code)
+
+				if node.Parent.Type != blackfriday.Item {
+					buf.WriteByte('\n')
+				}
+
+				return blackfriday.GoToNext
+			}
+		}
+
+		return renderer.RenderNode(&buf, node, entering)
+	})
+
+	renderer.RenderFooter(&buf, md.ast)
+
+	return template.HTML(buf.String())
+}
+
+// TOC nests the headings of the document under the nearest heading of a
+// higher level before them.
+func (md markdown) TOC() []Heading {
+
+	var toc []Heading
+
+	for _, heading := range md.headings {
+		toc = nestHeading(toc, heading)
+	}
+
+	return toc
+}
+
+func nestHeading(toc []Heading, heading Heading) []Heading {
+
+	if n := len(toc); n > 0 && toc[n-1].Level < heading.Level {
+		toc[n-1].Children = nestHeading(toc[n-1].Children, heading)
+		return toc
+	}
+
+	return append(toc, heading)
+}
+
+// markdownNodeText returns the text of a node, without markup.
+func markdownNodeText(node *blackfriday.Node) string {
+
+	var text strings.Builder
+
+	node.Walk(func(child *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+
+		if entering && (child.Type == blackfriday.Text || child.Type == blackfriday.Code) {
+			text.Write(child.Literal)
+		}
+
+		return blackfriday.GoToNext
+	})
+
+	return strings.TrimSpace(text.String())
+}
+
+// headingID turns a heading into an ID of lower case letters, digits and
+// dashes.
+func headingID(title string) string {
+
+	var id strings.Builder
+
+	dash := false
+
+	for _, r := range strings.ToLower(title) {
+
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+
+			if dash && id.Len() > 0 {
+				id.WriteByte('-')
+			}
+
+			id.WriteRune(r)
+			dash = false
+
+			continue
+		}
+
+		dash = true
+	}
+
+	if id.Len() == 0 {
+		return "section"
+	}
+
+	return id.String()
+}
@@ -0,0 +1,292 @@
+package kman
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/russross/blackfriday"
+)
+
+// admonitionTypes are the kinds of callout, each styled by the theme as
+// .admonition-{type}.
+var admonitionTypes = map[string]bool{
+	"note":      true,
+	"tip":       true,
+	"info":      true,
+	"important": true,
+	"warning":   true,
+	"caution":   true,
+	"danger":    true,
+}
+
+var (
+	// admonitionBlock starts a block such as `!!! warning "Title"`, whose
+	// content is indented below it, or follows it up to a blank line.
+	admonitionBlock = regexp.MustCompile(`^!!![ \t]+(\w+)(?:[ \t]+"([^"]*)")?[ \t]*$`)
+
+	// admonitionMarker starts a quote such as "> [!WARNING] Title".
+	admonitionMarker = regexp.MustCompile(`^\[!(\w+)\][ \t]*([^\n]*)\n?`)
+
+	taskMarker = regexp.MustCompile(`^\[([ xX])\][ \t]+`)
+)
+
+// admonitionBlocks rewrites "!!!" blocks as quotes marked with their type,
+// which blackfriday parses and admonitions then turns into callouts. Code
+// blocks are left alone.
+func admonitionBlocks(content string) string {
+
+	if !strings.Contains(content, "!!!") {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	output := make([]string, 0, len(lines))
+	fence := ""
+
+	for i := 0; i < len(lines); i++ {
+
+		line := lines[i]
+
+		if marker := codeFenceMarker(line); marker != "" {
+			switch {
+			case fence == "":
+				fence = marker
+
+			case strings.HasPrefix(marker, fence):
+				fence = ""
+			}
+		}
+
+		m := admonitionBlock.FindStringSubmatch(line)
+
+		if fence != "" || m == nil {
+			output = append(output, line)
+			continue
+		}
+
+		if n := len(output); n > 0 && strings.TrimSpace(output[n-1]) != "" {
+			output = append(output, "")
+		}
+
+		output = append(output, strings.TrimSpace(fmt.Sprintf("> [!%s] %s", m[1], m[2])))
+
+		// Topic files lose their indentation, so the lines right below the
+		// start belong to the block either way. After a blank line, only
+		// indented lines do.
+		blank := false
+
+		for ; i+1 < len(lines); i++ {
+
+			next := lines[i+1]
+
+			if strings.TrimSpace(next) == "" {
+				blank = true
+				output = append(output, ">")
+				continue
+			}
+
+			if indented(next) {
+				next = dedent(next)
+			} else if blank {
+				break
+			}
+
+			output = append(output, "> "+next)
+		}
+
+		// Keep the next paragraph out of the quote.
+		output = append(output, "")
+	}
+
+	return strings.Join(output, "\n")
+}
+
+func indented(line string) bool {
+	return strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")
+}
+
+func dedent(line string) string {
+
+	if strings.HasPrefix(line, "\t") {
+		return line[1:]
+	}
+
+	return line[4:]
+}
+
+// codeFenceMarker returns the ``` or ~~~ run opening or closing a fenced
+// code block, if the line is one.
+func codeFenceMarker(line string) string {
+
+	trimmed := strings.TrimLeft(line, " ")
+
+	if len(line)-len(trimmed) > 3 || trimmed == "" || (trimmed[0] != '`' && trimmed[0] != '~') {
+		return ""
+	}
+
+	n := 0
+
+	for n < len(trimmed) && trimmed[n] == trimmed[0] {
+		n++
+	}
+
+	if n < 3 {
+		return ""
+	}
+
+	return trimmed[:n]
+}
+
+// admonitions replaces quotes starting with an admonition label, such as
+// "**Note**", "**Warning:**" or "[!TIP] Title", by a div of class
+// admonition, titled with the label.
+func admonitions(ast *blackfriday.Node) {
+
+	var quotes []*blackfriday.Node
+
+	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+
+		if entering && node.Type == blackfriday.BlockQuote {
+			quotes = append(quotes, node)
+		}
+
+		return blackfriday.GoToNext
+	})
+
+	for _, quote := range quotes {
+
+		kind, title, ok := admonitionLabel(quote.FirstChild)
+
+		if !ok {
+			continue
+		}
+
+		open := blackfriday.NewNode(blackfriday.HTMLBlock)
+		open.Literal = []byte(fmt.Sprintf("<div class=\"admonition admonition-%s\">\n<p class=\"admonition-title\">%s</p>", kind, html.EscapeString(title)))
+		quote.InsertBefore(open)
+
+		for child := quote.FirstChild; child != nil; child = quote.FirstChild {
+			child.Unlink()
+			quote.InsertBefore(child)
+		}
+
+		end := blackfriday.NewNode(blackfriday.HTMLBlock)
+		end.Literal = []byte("</div>")
+		quote.InsertBefore(end)
+
+		quote.Unlink()
+	}
+}
+
+// admonitionLabel removes the label from the first paragraph of a quote,
+// returning the type and title it gives.
+func admonitionLabel(paragraph *blackfriday.Node) (kind, title string, ok bool) {
+
+	if paragraph == nil || paragraph.Type != blackfriday.Paragraph {
+		return
+	}
+
+	first := paragraph.FirstChild
+
+	// Blackfriday puts an empty text before leading emphasis.
+	if first != nil && first.Type == blackfriday.Text && len(first.Literal) == 0 && first.Next != nil {
+		first = first.Next
+	}
+
+	switch {
+	case first == nil:
+		return
+
+	case first.Type == blackfriday.Strong:
+		title = strings.TrimSpace(strings.TrimSuffix(markdownNodeText(first), ":"))
+		kind = strings.ToLower(title)
+
+		if !admonitionTypes[kind] {
+			return "", "", false
+		}
+
+		next := first.Next
+
+		for paragraph.FirstChild != next {
+			paragraph.FirstChild.Unlink()
+		}
+
+		if next != nil && next.Type == blackfriday.Text {
+			next.Literal = []byte(strings.TrimLeft(string(next.Literal), ": \t\n"))
+		}
+
+	case first.Type == blackfriday.Text:
+		m := admonitionMarker.FindSubmatch(first.Literal)
+
+		if m == nil || !admonitionTypes[strings.ToLower(string(m[1]))] {
+			return
+		}
+
+		kind = strings.ToLower(string(m[1]))
+		title = strings.TrimSpace(string(m[2]))
+		first.Literal = first.Literal[len(m[0]):]
+
+		if title == "" {
+			title = strings.ToUpper(kind[:1]) + kind[1:]
+		}
+
+	default:
+		return
+	}
+
+	// Drop what is left of the paragraph if the label was all it held.
+	for child := paragraph.FirstChild; child != nil; child = paragraph.FirstChild {
+
+		if child.Type != blackfriday.Text || len(child.Literal) > 0 {
+			break
+		}
+
+		child.Unlink()
+	}
+
+	if paragraph.FirstChild == nil {
+		paragraph.Unlink()
+	}
+
+	return kind, title, true
+}
+
+// taskLists turns list items starting with "[ ]" or "[x]" into checkboxes.
+func taskLists(ast *blackfriday.Node) {
+
+	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+
+		if !entering || node.Type != blackfriday.Item {
+			return blackfriday.GoToNext
+		}
+
+		paragraph := node.FirstChild
+
+		if paragraph == nil || paragraph.Type != blackfriday.Paragraph || paragraph.FirstChild == nil || paragraph.FirstChild.Type != blackfriday.Text {
+			return blackfriday.GoToNext
+		}
+
+		text := paragraph.FirstChild
+		m := taskMarker.FindSubmatch(text.Literal)
+
+		if m == nil {
+			return blackfriday.GoToNext
+		}
+
+		checked := ""
+
+		if m[1][0] != ' ' {
+			checked = ` checked="checked"`
+		}
+
+		box := blackfriday.NewNode(blackfriday.HTMLSpan)
+		box.Literal = []byte(`<input type="checkbox" class="task" disabled="disabled"` + checked + ` /> `)
+
+		text.Literal = text.Literal[len(m[0]):]
+		text.InsertBefore(box)
+
+		return blackfriday.GoToNext
+	})
+}
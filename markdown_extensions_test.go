@@ -0,0 +1,102 @@
+package kman
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AdmonitionsShouldRenderAsCallouts(t *testing.T) {
+
+	for i, c := range []struct {
+		description string
+		content     string
+		expected    string
+	}{
+		{
+			"Bold label on its own line",
+			"> **Warning**\n> Mind the gap.",
+			"<div class=\"admonition admonition-warning\">\n<p class=\"admonition-title\">Warning</p>\n\n<p>Mind the gap.</p>\n\n</div>\n",
+		},
+		{
+			"Bold label with a colon, in the text",
+			"> **Note:** Mind the gap.",
+			"<div class=\"admonition admonition-note\">\n<p class=\"admonition-title\">Note</p>\n\n<p>Mind the gap.</p>\n\n</div>\n",
+		},
+		{
+			"Marker with a title",
+			"> [!TIP] Save time\n> Use the cache.",
+			"<div class=\"admonition admonition-tip\">\n<p class=\"admonition-title\">Save time</p>\n\n<p>Use the cache.</p>\n\n</div>\n",
+		},
+		{
+			"Block",
+			"Before\n!!! danger \"Do not\"\n    Run this.\n\n    Or that.\nAfter",
+			"<p>Before</p>\n\n<div class=\"admonition admonition-danger\">\n<p class=\"admonition-title\">Do not</p>\n\n<p>Run this.</p>\n\n<p>Or that.</p>\n\n</div>\n\n<p>After</p>\n",
+		},
+		{
+			"Block without indentation",
+			"!!! note\nRun this.\n\nAfter",
+			"<div class=\"admonition admonition-note\">\n<p class=\"admonition-title\">Note</p>\n\n<p>Run this.</p>\n\n</div>\n\n<p>After</p>\n",
+		},
+		{
+			"Plain quote",
+			"> **Bold** claim",
+			"<blockquote>\n<p><strong>Bold</strong> claim</p>\n</blockquote>\n",
+		},
+		{
+			"Unknown type",
+			"> [!NOPE] x",
+			"<blockquote>\n<p>[!NOPE] x</p>\n</blockquote>\n",
+		},
+		{
+			"Block in code",
+			"```\n!!! note\n    x\n```",
+			"<pre><code>!!! note\n    x\n</code></pre>\n",
+		},
+	} {
+		t.Run(fmt.Sprintf("Cycle %d: %s", i, c.description), func(t *testing.T) {
+			require.Equal(t, c.expected, string(parseMarkdown(c.content, "", DefaultMarkdownOptions()).HTML()))
+		})
+	}
+}
+
+func Test_MarkdownExtensionsCanBeTurnedOnAndOff(t *testing.T) {
+
+	content := "- [ ] todo\n- [x] done\n\nText[^1] -- \"quoted\"\n\n[^1]: A note.\n\n> **Note** x"
+
+	html := string(parseMarkdown(content, "", DefaultMarkdownOptions()).HTML())
+
+	require.Contains(t, html, "<li>[ ] todo</li>")
+	require.Contains(t, html, "[^1]")
+	require.Contains(t, html, "&ndash; &ldquo;quoted&rdquo;")
+	require.Contains(t, html, "admonition-note")
+
+	html = string(parseMarkdown(content, "term-", MarkdownOptions{TaskLists: true, Footnotes: true}).HTML())
+
+	require.Contains(t, html, `<li><input type="checkbox" class="task" disabled="disabled" /> todo</li>`)
+	require.Contains(t, html, `<li><input type="checkbox" class="task" disabled="disabled" checked="checked" /> done</li>`)
+	require.Contains(t, html, `href="#fn:term-1"`)
+	require.Contains(t, html, "-- &quot;quoted&quot;")
+	require.Contains(t, html, "<blockquote>")
+}
+
+func Test_DocumentationCanBeRenderedWithMarkdownOptions(t *testing.T) {
+
+	d := Documentation{
+		RootTopic: TopicRef{
+			Item:     Item{Content: "a -- b"},
+			Children: []TopicRef{{Item: Item{Content: "c -- d"}}},
+		},
+		Glossary: []TermRef{{Item: Item{Handle: "e", Content: "e -- f"}}},
+	}
+
+	plain := d.WithMarkdownOptions(MarkdownOptions{})
+
+	require.Equal(t, "<p>a -- b</p>\n", string(plain.RootTopic.HTML()))
+	require.Equal(t, "<p>c -- d</p>\n", string(plain.RootTopic.Children[0].HTML()))
+	require.Equal(t, "<p>e -- f</p>\n", string(plain.Glossary[0].HTML()))
+
+	// The original is left alone.
+	require.Equal(t, "<p>c &ndash; d</p>\n", string(d.RootTopic.Children[0].HTML()))
+}
@@ -0,0 +1,78 @@
+package kman
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MarkdownHeadingsShouldHaveUniqueIDs(t *testing.T) {
+
+	for i, c := range []struct {
+		description string
+		content     string
+		prefix      string
+		expected    string
+	}{
+		{
+			"Generated from the text",
+			"# Getting *started*\n\n## Use `kman build`, now!",
+			"",
+			"<h1 id=\"getting-started\">Getting <em>started</em></h1>\n\n<h2 id=\"use-kman-build-now\">Use <code>kman build</code>, now!</h2>\n",
+		},
+		{
+			"Numbered when repeated",
+			"## Example\n\n## Example\n\n## Example",
+			"",
+			"<h2 id=\"example\">Example</h2>\n\n<h2 id=\"example-1\">Example</h2>\n\n<h2 id=\"example-2\">Example</h2>\n",
+		},
+		{
+			"Explicit IDs kept",
+			"## Options {#opts}\n\n## Opts",
+			"",
+			"<h2 id=\"opts\">Options</h2>\n\n<h2 id=\"opts-1\">Opts</h2>\n",
+		},
+		{
+			"Prefixed",
+			"## Usage",
+			"term-",
+			"<h2 id=\"term-usage\">Usage</h2>\n",
+		},
+		{
+			"Without letters or digits",
+			"## ???",
+			"",
+			"<h2 id=\"section\">???</h2>\n",
+		},
+	} {
+		t.Run(fmt.Sprintf("Cycle %d: %s", i, c.description), func(t *testing.T) {
+			require.Equal(t, c.expected, string(parseMarkdown(c.content, c.prefix, DefaultMarkdownOptions()).HTML()))
+		})
+	}
+}
+
+func Test_MarkdownHeadingsShouldNestInATableOfContents(t *testing.T) {
+
+	toc := Item{Content: "# A\n\n### A.1\n\n## A.2\n\n### A.2.1\n\n# B\n\n## B.1"}.TOC()
+
+	require.Equal(t, []Heading{
+		{Level: 1, Title: "A", ID: "a", Children: []Heading{
+			{Level: 3, Title: "A.1", ID: "a-1"},
+			{Level: 2, Title: "A.2", ID: "a-2", Children: []Heading{
+				{Level: 3, Title: "A.2.1", ID: "a-2-1"},
+			}},
+		}},
+		{Level: 1, Title: "B", ID: "b", Children: []Heading{
+			{Level: 2, Title: "B.1", ID: "b-1"},
+		}},
+	}, toc)
+}
+
+func Test_ATermsHeadingsShouldStartWithItsHandle(t *testing.T) {
+
+	term := TermRef{Item: Item{Handle: "cache", Content: "## Usage"}}
+
+	require.Equal(t, "<h2 id=\"cache-usage\">Usage</h2>\n", string(term.HTML()))
+	require.Equal(t, []Heading{{Level: 2, Title: "Usage", ID: "cache-usage"}}, term.TOC())
+}
@@ -0,0 +1,112 @@
+package kman
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	LastModifiedFile = "mtime"
+	LastModifiedGit  = "git"
+)
+
+// ModTimeFunc returns when a source file was last modified, if known.
+type ModTimeFunc func(fileName string) (time.Time, bool)
+
+// FileModTimes takes modification times from the filesystem.
+func FileModTimes(fs afero.Fs) ModTimeFunc {
+	return func(fileName string) (time.Time, bool) {
+
+		info, err := fs.Stat(fileName)
+
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		return info.ModTime(), true
+	}
+}
+
+// GitModTimes takes modification times from the last commit changing each
+// file in the git repository holding dir, which unlike file times survive a
+// fresh clone. File names are relative to dir.
+func GitModTimes(dir string) (ModTimeFunc, error) {
+
+	prefix, err := git(dir, "rev-parse", "--show-prefix")
+
+	if err != nil {
+		return nil, err
+	}
+
+	log, err := git(dir, "log", "--format=%x00%cI", "--name-only", "--no-renames")
+
+	if err != nil {
+		return nil, err
+	}
+
+	times := make(map[string]time.Time)
+	scanner := bufio.NewScanner(bytes.NewReader(log))
+
+	var commit time.Time
+
+	// Commits are listed newest first, so the first one naming a file is
+	// the last to have changed it.
+	for scanner.Scan() {
+
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "\x00") {
+			commit, err = time.Parse(time.RFC3339, line[1:])
+
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if line == "" || !strings.HasPrefix(line, string(prefix)) {
+			continue
+		}
+
+		file := strings.TrimPrefix(line, string(prefix))
+
+		if _, ok := times[file]; !ok {
+			times[file] = commit
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return func(fileName string) (time.Time, bool) {
+		t, ok := times[filepath.ToSlash(filepath.Clean(fileName))]
+		return t, ok
+	}, nil
+}
+
+func git(dir string, args ...string) ([]byte, error) {
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return nil, fmt.Errorf("git %s: %s", args[0], bytes.TrimSpace(exitErr.Stderr))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimRight(out, "\n"), nil
+}
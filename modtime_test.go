@@ -0,0 +1,27 @@
+package kman
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FileModTimesShouldComeFromTheFilesystem(t *testing.T) {
+
+	fs := newMockFilesystem(t, map[string]string{
+		"doc/topics.md": "Topic: Example",
+	})
+
+	modified := time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.Nil(t, fs.Chtimes("doc/topics.md", modified, modified))
+
+	modTime := FileModTimes(fs)
+
+	found, ok := modTime("doc/topics.md")
+	require.True(t, ok)
+	require.True(t, modified.Equal(found))
+
+	_, ok = modTime("doc/missing.md")
+	require.False(t, ok)
+}
@@ -0,0 +1,54 @@
+package kman
+
+import (
+	"runtime"
+	"sync"
+)
+
+// parallelism bounds the number of goroutines used to parse files and
+// render pages, across a whole build.
+var parallelism = runtime.NumCPU()
+
+// workers are the goroutines every call to parallel shares, beside the one
+// calling it, so that nested calls stay within the bound.
+var workers = make(chan struct{}, parallelism-1)
+
+// parallel calls work for every index in [0, n) on the shared pool of
+// goroutines, or on the calling goroutine while the pool is busy, so that
+// nested calls can't deadlock waiting for each other. Work results should be
+// stored by index, to keep the output independent of scheduling. The error
+// returned is that of the lowest index which failed.
+func parallel(n int, work func(i int) error) error {
+
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+
+		select {
+		case workers <- struct{}{}:
+			wg.Add(1)
+
+			go func(i int) {
+				defer wg.Done()
+
+				errs[i] = work(i)
+				<-workers
+			}(i)
+
+		default:
+			errs[i] = work(i)
+		}
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package kman
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParallelWorkShouldCoverEveryIndex(t *testing.T) {
+
+	for _, n := range []int{0, 1, parallelism, parallelism*3 + 1} {
+
+		results := make([]int, n)
+
+		require.Nil(t, parallel(n, func(i int) error {
+			results[i] = i * i
+			return nil
+		}))
+
+		for i, result := range results {
+			require.Equal(t, i*i, result)
+		}
+	}
+}
+
+func Test_ParallelWorkShouldReturnTheFirstErrorByIndex(t *testing.T) {
+
+	err := parallel(20, func(i int) error {
+
+		if i%5 == 3 {
+			return fmt.Errorf("failed %d", i)
+		}
+
+		return nil
+	})
+
+	require.Equal(t, errors.New("failed 3"), err)
+}
+
+func Test_NestedParallelWorkShouldShareTheBound(t *testing.T) {
+
+	var mu sync.Mutex
+	running, most := 0, 0
+
+	require.Nil(t, parallel(parallelism+1, func(int) error {
+		return parallel(parallelism*2, func(int) error {
+
+			mu.Lock()
+			running++
+			if running > most {
+				most = running
+			}
+			mu.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+
+			return nil
+		})
+	}))
+
+	require.True(t, most <= parallelism, "%d running at once, for %d", most, parallelism)
+}
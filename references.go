@@ -0,0 +1,167 @@
+package kman
+
+import (
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/russross/blackfriday"
+)
+
+// topicReferences are the site paths a topic links to, and its text without
+// markup or code, in lower case.
+type topicReferences struct {
+	links map[string]bool
+	text  string
+}
+
+// crossReference fills in the backlinks of every topic, the terms each topic
+// uses, and the topics using each term. Topics link to each other by site
+// path, such as "/usage/advanced", and use a term by linking to it, as in
+// "/glossary#handle", or by mentioning its title.
+func crossReference(d Documentation) Documentation {
+
+	type page struct {
+		url   string
+		topic *TopicRef
+		refs  topicReferences
+	}
+
+	var pages []page
+
+	var walk func(url string, topic *TopicRef)
+
+	walk = func(url string, topic *TopicRef) {
+
+		pages = append(pages, page{url, topic, findReferences(topic.Item)})
+
+		for i := range topic.Children {
+			walk(path.Join(url, topic.Children[i].Handle), &topic.Children[i])
+		}
+	}
+
+	if d.RootTopic.Title != "" {
+		walk("/", &d.RootTopic)
+	}
+
+	for _, source := range pages {
+		for _, target := range pages {
+			if target.url != source.url && source.refs.links[target.url] {
+				target.topic.Backlinks = append(target.topic.Backlinks, PageRef{URL: source.url, Item: source.topic.Item})
+			}
+		}
+	}
+
+	for i := range d.Glossary {
+
+		term := &d.Glossary[i]
+		url := "/glossary#" + term.Handle
+
+		for _, p := range pages {
+			if p.refs.links[url] || mentions(p.refs.text, term.Title) {
+				p.topic.Terms = append(p.topic.Terms, PageRef{URL: url, Item: term.Item})
+				term.UsedIn = append(term.UsedIn, PageRef{URL: p.url, Item: p.topic.Item})
+			}
+		}
+	}
+
+	return d
+}
+
+// findReferences reads the links and text of the content of an item.
+func findReferences(item Item) topicReferences {
+
+	refs := topicReferences{links: map[string]bool{}}
+
+	var text strings.Builder
+
+	parseMarkdown(item.Content, "", item.markdownOptions()).ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+
+		if !entering {
+			return blackfriday.GoToNext
+		}
+
+		switch node.Type {
+		case blackfriday.Link:
+			if url, ok := sitePath(string(node.LinkData.Destination)); ok {
+				refs.links[url] = true
+			}
+
+		case blackfriday.Text:
+			text.Write(node.Literal)
+			text.WriteByte(' ')
+
+		case blackfriday.Paragraph, blackfriday.Heading, blackfriday.Item, blackfriday.TableCell:
+			text.WriteByte('\n')
+		}
+
+		return blackfriday.GoToNext
+	})
+
+	refs.text = strings.ToLower(text.String())
+
+	return refs
+}
+
+// sitePath returns the page a root-relative link points at, keeping the
+// fragment of glossary links only.
+func sitePath(link string) (string, bool) {
+
+	if !strings.HasPrefix(link, "/") || strings.HasPrefix(link, "//") {
+		return "", false
+	}
+
+	fragment := ""
+
+	if i := strings.IndexByte(link, '#'); i >= 0 {
+		link, fragment = link[:i], link[i:]
+	}
+
+	if i := strings.IndexByte(link, '?'); i >= 0 {
+		link = link[:i]
+	}
+
+	link = path.Clean(strings.TrimSuffix(link, "/index.html"))
+
+	if link == "/glossary" {
+		return link + fragment, fragment != ""
+	}
+
+	return link, true
+}
+
+// mentions reports whether text, in lower case, holds title as a whole word
+// or phrase.
+func mentions(text, title string) bool {
+
+	title = strings.ToLower(strings.TrimSpace(title))
+
+	if title == "" {
+		return false
+	}
+
+	for offset := 0; ; {
+
+		i := strings.Index(text[offset:], title)
+
+		if i < 0 {
+			return false
+		}
+
+		start, end := offset+i, offset+i+len(title)
+
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+
+		offset = start + 1
+	}
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
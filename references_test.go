@@ -0,0 +1,63 @@
+package kman
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ADefaultDocumenterShouldCrossReferenceTopicsAndTerms(t *testing.T) {
+
+	docer := NewDefaultDocumenter(NewDefaultSorter(), &mockAssembler{[]Item{
+		{Type: ItemTypeTopic, Handle: "root", Title: "Home", Content: "Start with [usage](/usage/), or read about [Validators](/glossary#validator)."},
+		{Type: ItemTypeTopic, Handle: "usage", Title: "Usage", Content: "Run a node. See [advanced](/usage/advanced/index.html#flags) and [us](/usage)."},
+		{Type: ItemTypeTopic, Handle: "usage_advanced", Title: "Advanced", Content: "Back [home](/?x=1). `node` in code is no mention, nor are nodes."},
+		{Type: ItemTypeTerm, Handle: "node", Title: "Node", Content: "A computer."},
+		{Type: ItemTypeTerm, Handle: "validator", Title: "Validator", Content: "A node which validates."},
+	}})
+
+	doc, err := docer.Document()
+	require.Nil(t, err)
+
+	urls := func(refs []PageRef) (output []string) {
+		for _, ref := range refs {
+			output = append(output, ref.URL)
+		}
+		return
+	}
+
+	home, usage, advanced := doc.RootTopic, doc.RootTopic.Children[0], doc.RootTopic.Children[0].Children[0]
+
+	require.Equal(t, []string{"/usage/advanced"}, urls(home.Backlinks))
+	require.Equal(t, []string{"/"}, urls(usage.Backlinks))
+	require.Equal(t, []string{"/usage"}, urls(advanced.Backlinks))
+
+	require.Equal(t, []string{"/glossary#validator"}, urls(home.Terms))
+	require.Equal(t, []string{"/glossary#node"}, urls(usage.Terms))
+	require.Empty(t, advanced.Terms)
+
+	require.Equal(t, []string{"/usage"}, urls(doc.Glossary[0].UsedIn))
+	require.Equal(t, []string{"/"}, urls(doc.Glossary[1].UsedIn))
+}
+
+func Test_TitlesShouldOnlyBeMentionedAsWholeWords(t *testing.T) {
+
+	for i, c := range []struct {
+		text     string
+		title    string
+		expected bool
+	}{
+		{"run a node.", "Node", true},
+		{"nodes", "Node", false},
+		{"a subnode", "node", false},
+		{"subnode and node", "node", true},
+		{"the proof of stake (pos) chain", "Proof of Stake", true},
+		{"über-knoten", "Knoten", true},
+		{"", "Node", false},
+	} {
+		t.Run(fmt.Sprintf("Cycle %d: %s", i, c.text), func(t *testing.T) {
+			require.Equal(t, c.expected, mentions(c.text, c.title))
+		})
+	}
+}
@@ -1,5 +1,48 @@
 package kman
 
+import "github.com/spf13/afero"
+
 type Renderer interface {
 	Render(Documentation) error
 }
+
+type RendererOptions struct {
+	Site Site
+
+	// Theme, if set, holds the templates and assets at its root, as returned
+	// by LoadTheme.
+	Theme afero.Fs
+
+	// Engine, if set, compiles the templates of the theme instead of the
+	// engine the theme asks for.
+	Engine TemplateEngine
+
+	// Fingerprint renames CSS, JavaScript and image assets after a hash of
+	// their content, so that they can be cached for ever. Templates link to
+	// them with the asset function.
+	Fingerprint bool
+
+	// BaseURL is where the site is hosted. Links within the site are
+	// prefixed with its path.
+	BaseURL string
+
+	// RelativeLinks makes links within the site relative to each page, so
+	// that it can be opened from disk or moved to any path.
+	RelativeLinks bool
+
+	// ModTime, if set, gives the last modification dates of the sitemap.
+	ModTime ModTimeFunc
+
+	// Disallow lists the paths robots.txt asks crawlers to stay out of,
+	// unless the theme has its own robots.txt.
+	Disallow []string
+
+	// Cache, if set, lets renderers skip pages whose inputs have not changed
+	// since the last build.
+	Cache Cache
+
+	// Manifests, if set, keeps the list of the files each build writes, so
+	// that the next build removes those it no longer writes, whether or not
+	// it is cached. It defaults to Cache.
+	Manifests Cache
+}
@@ -2,21 +2,75 @@ package kman
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/spf13/afero"
-	"github.com/yosssi/ace"
 )
 
+// searchIndexPath is where the search index is written, relative to the
+// output path.
+const searchIndexPath = "js/search-index.js"
+
 type rendererAce struct {
-	fs           afero.Fs
-	templatePath string
-	outputPath   string
+	fs         afero.Fs
+	theme      afero.Fs
+	engine     TemplateEngine
+	linker     linker
+	outputPath string
+	options    RendererOptions
+
+	templateHash []byte
+	docHash      string
+	previous     rendererAceManifest
+
+	// tagPages is whether the documentation has tags, and the theme
+	// templates for their pages.
+	tagPages bool
+
+	mu        sync.Mutex
+	templates map[string]*template.Template
+	pages     map[string]string
+	assets    map[string]string
+	written   map[string]string
+}
+
+// rendererAcePage is a page to render, from the template src to the
+// directory dest.
+type rendererAcePage struct {
+	src     string
+	dest    string
+	title   string
+	context interface{}
+}
+
+// rendererAceManifest records the pages written by the last build, and the
+// hash of the inputs each was rendered from, and the assets it wrote with
+// the hash of their content.
+type rendererAceManifest struct {
+	Pages  map[string]string
+	Assets map[string]string
+}
+
+// fingerprintExtensions lists the assets renamed after their content when
+// fingerprinting is on. Icons keep their names, as browsers look them up.
+var fingerprintExtensions = map[string]bool{
+	".css":  true,
+	".js":   true,
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".gif":  true,
+	".svg":  true,
+	".webp": true,
 }
 
 type rendererAceNavigation struct {
@@ -54,31 +108,319 @@ func (r *rendererAceNavigation) flattenToList(list *[]rendererAceNavigation) {
 	}
 }
 
+// breadcrumbs returns the ancestors of the active page, from the root down.
+func (r *rendererAceNavigation) breadcrumbs() (output []rendererAceNavigation) {
+
+	for branch := r; branch != nil && branch.ActiveChild; {
+
+		branch.addToList(&output)
+
+		next := branch
+		branch = nil
+
+		for i := range next.Children {
+			if next.Children[i].Active || next.Children[i].ActiveChild {
+				branch = &next.Children[i]
+				break
+			}
+		}
+	}
+
+	return
+}
+
+// pager returns the topics before and after the active page, in depth-first
+// order. The glossary and tags are not topics, so they have neither.
+func (r *rendererAceNavigation) pager() (previous, next *rendererAceNavigation) {
+
+	var topics []rendererAceNavigation
+
+	for _, item := range r.flatten() {
+		if item.URL != "/glossary" && item.URL != "/tags" {
+			topics = append(topics, item)
+		}
+	}
+
+	for i := range topics {
+
+		if !topics[i].Active {
+			continue
+		}
+
+		if i > 0 {
+			previous = &topics[i-1]
+		}
+
+		if i < len(topics)-1 {
+			next = &topics[i+1]
+		}
+	}
+
+	return
+}
+
+// NewRendererAce renders the theme at templatePath on fs to outputPath. Ace
+// is the default template engine, but themes may use any TemplateEngine.
 func NewRendererAce(fs afero.Fs, templatePath, outputPath string) Renderer {
+	return NewRendererAceWithOptions(fs, templatePath, outputPath, RendererOptions{
+		Site: DefaultConfig().Site,
+	})
+}
+
+// NewRendererAceWithOptions renders to outputPath on fs. The theme is read
+// from templatePath on fs, unless the options give one.
+func NewRendererAceWithOptions(fs afero.Fs, templatePath, outputPath string, options RendererOptions) Renderer {
+
+	theme := options.Theme
+
+	if theme == nil {
+		theme = afero.NewBasePathFs(fs, templatePath)
+	}
+
 	return &rendererAce{
-		fs:           fs,
-		templatePath: templatePath,
-		outputPath:   outputPath,
+		fs:         fs,
+		theme:      theme,
+		outputPath: outputPath,
+		options:    options,
 	}
 }
 
 func (r *rendererAce) Render(d Documentation) error {
 
-	if err := r.executeTemplate("index", "", d, d.RootTopic.Title, d.RootTopic); err != nil {
+	r.templates = make(map[string]*template.Template)
+	r.engine = r.options.Engine
+
+	linker, err := newLinker(r.options.BaseURL, r.options.RelativeLinks)
+
+	if err != nil {
+		return err
+	}
+
+	r.linker = linker
+
+	if r.engine == nil {
+
+		engine, err := NewTemplateEngine(r.theme)
+
+		if err != nil {
+			return err
+		}
+
+		r.engine = engine
+	}
+
+	if err := r.startManifest(); err != nil {
+		return err
+	}
+
+	// Every page gets the whole documentation, and may show any of it.
+	if r.docHash, err = r.inputHash("doc", d); err != nil {
+		return err
+	}
+
+	// Assets come first, so that pages can link to their fingerprinted names.
+	if err := r.copyAssets(); err != nil {
+		return err
+	}
+
+	if err := r.writeSearchIndex(d); err != nil {
+		return err
+	}
+
+	if err := r.writeHighlightCSS(); err != nil {
 		return err
 	}
 
+	pages := []rendererAcePage{
+		rendererAcePage{"index", "", d.RootTopic.Title, d.RootTopic},
+	}
+
 	for _, topic := range d.RootTopic.Children {
-		if err := r.renderTopic("", d, topic); err != nil {
+		r.topicPages("", topic, &pages)
+	}
+
+	pages = append(pages, rendererAcePage{"glossary", "glossary", "Glossary", d.Glossary})
+
+	r.tagPages = len(d.Tags) > 0 && r.engine.Has(r.theme, "tags") && r.engine.Has(r.theme, "tag")
+
+	if r.tagPages {
+
+		pages = append(pages, rendererAcePage{"tags", "tags", "Tags", d.Tags})
+
+		for _, tag := range d.Tags {
+			pages = append(pages, rendererAcePage{"tag", path.Join("tags", tag.Handle), tag.Name, tag})
+		}
+	}
+
+	err = parallel(len(pages), func(i int) error {
+		return r.executeTemplate(pages[i].src, pages[i].dest, d, pages[i].title, pages[i].context)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err := r.writeSitemap(pages); err != nil {
+		return err
+	}
+
+	if err := r.writeRobots(); err != nil {
+		return err
+	}
+
+	return r.finishManifest()
+}
+
+// writeHighlightCSS writes the styles of highlighted code chosen by the
+// theme, unless it has its own.
+func (r *rendererAce) writeHighlightCSS() error {
+
+	if _, ok := r.assets[highlightCSSPath]; ok {
+		return nil
+	}
+
+	config, err := readThemeConfig(r.theme)
+
+	if err != nil {
+		return err
+	}
+
+	css, err := HighlightCSS(config.Highlight.styles())
+
+	if err != nil {
+		return err
+	}
+
+	return r.writeAsset(highlightCSSPath, css, 0644)
+}
+
+// writeSearchIndex writes the full text search index as a script, rather
+// than JSON, so that search works when pages are opened from disk.
+func (r *rendererAce) writeSearchIndex(d Documentation) error {
+
+	index, err := json.Marshal(NewSearchIndex(d))
+
+	if err != nil {
+		return err
+	}
+
+	script := fmt.Sprintf("window.SearchIndex = %s;\n", index)
+
+	return r.writeAsset(searchIndexPath, []byte(script), 0644)
+}
+
+func (r *rendererAce) manifestKey() string {
+	return "render:" + r.outputPath
+}
+
+// manifests returns where the manifests of builds are kept, if anywhere.
+func (r *rendererAce) manifests() Cache {
+
+	if r.options.Manifests != nil {
+		return r.options.Manifests
+	}
+
+	return r.options.Cache
+}
+
+// startManifest loads the manifest of the previous build, and hashes the
+// theme, as a change to any template may change every page.
+func (r *rendererAce) startManifest() error {
+
+	r.previous = rendererAceManifest{}
+	r.pages = make(map[string]string)
+	r.assets = make(map[string]string)
+	r.written = make(map[string]string)
+
+	// A missing manifest only leaves stale files behind.
+	if manifests := r.manifests(); manifests != nil {
+		manifests.Get(r.manifestKey(), &r.previous)
+	}
+
+	if r.options.Cache == nil {
+		return nil
+	}
+
+	hash := sha256.New()
+
+	err := afero.Walk(r.theme, themeRoot, func(path string, info os.FileInfo, err error) error {
+
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		data, err := afero.ReadFile(r.theme, path)
+
+		if err != nil {
 			return err
 		}
+
+		fmt.Fprintf(hash, "%s\n%d\n", path, len(data))
+		hash.Write(data)
+
+		return nil
+	})
+
+	r.templateHash = hash.Sum(nil)
+
+	return err
+}
+
+// finishManifest removes the pages and assets written by the previous build
+// that this one did not write, and records those of this one.
+func (r *rendererAce) finishManifest() error {
+
+	if r.manifests() == nil {
+		return nil
 	}
 
-	if err := r.executeTemplate("glossary", "glossary", d, "Glossary", d.Glossary); err != nil {
+	if err := r.removeStale(r.previous.Pages, r.pages); err != nil {
 		return err
 	}
 
-	return r.copyAssets()
+	if err := r.removeStale(r.previous.Assets, r.written); err != nil {
+		return err
+	}
+
+	return r.manifests().Set(r.manifestKey(), rendererAceManifest{
+		Pages:  r.pages,
+		Assets: r.written,
+	})
+}
+
+func (r *rendererAce) removeStale(previous, current map[string]string) error {
+
+	for file := range previous {
+
+		if _, ok := current[file]; ok {
+			continue
+		}
+
+		if err := r.fs.Remove(file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		// Not every afero filesystem refuses to remove a directory that is
+		// not empty.
+		if empty, _ := afero.IsEmpty(r.fs, filepath.Dir(file)); empty {
+			r.fs.Remove(filepath.Dir(file))
+		}
+	}
+
+	return nil
+}
+
+// unchanged reports whether a file was written from the same inputs by the
+// previous build, and is still in place.
+func (r *rendererAce) unchanged(previous map[string]string, path, hash string) bool {
+
+	if r.options.Cache == nil || previous[path] != hash {
+		return false
+	}
+
+	exists, _ := afero.Exists(r.fs, path)
+
+	return exists
 }
 
 func (r *rendererAce) navigation(d Documentation, currentPath string) (nav rendererAceNavigation) {
@@ -110,6 +452,22 @@ func (r *rendererAce) navigation(d Documentation, currentPath string) (nav rende
 		nav.Children = append(nav.Children, glossary)
 	}
 
+	if r.tagPages {
+
+		tags := rendererAceNavigation{
+			Title: "Tags",
+			URL:   "/tags",
+		}
+
+		if currentPath == "/tags" {
+			tags.Active = true
+		} else if strings.HasPrefix(currentPath, "/tags/") {
+			tags.ActiveChild = true
+		}
+
+		nav.Children = append(nav.Children, tags)
+	}
+
 	return
 }
 
@@ -126,7 +484,7 @@ func (r *rendererAce) navigationBranch(root string, currentPath string, items []
 
 		if currentPath == url {
 			branch.Active = true
-		} else if strings.HasPrefix(currentPath, url) {
+		} else if strings.HasPrefix(currentPath, url+"/") {
 			branch.ActiveChild = true
 		}
 
@@ -136,110 +494,214 @@ func (r *rendererAce) navigationBranch(root string, currentPath string, items []
 	}
 }
 
+// copyAssets copies every file of the theme other than its templates to the
+// output.
 func (r *rendererAce) copyAssets() error {
 
-	return afero.Walk(r.fs, r.templatePath, func(path string, info os.FileInfo, err error) error {
-
-		if !info.IsDir() && info.Size() > 0 && filepath.Ext(path) != ".ace" {
-			dest := filepath.Join(r.outputPath, strings.TrimPrefix(path, r.templatePath+"/"))
+	return afero.Walk(r.theme, themeRoot, func(path string, info os.FileInfo, err error) error {
 
-			if err := r.fs.MkdirAll(filepath.Base(dest), os.ModePerm); err != nil {
-				return err
-			}
+		if err != nil || info.IsDir() || r.engine.Template(path) || path == themeRoot+ThemeConfigFile {
+			return err
+		}
 
-			data, err := afero.ReadFile(r.fs, path)
+		data, err := afero.ReadFile(r.theme, path)
 
-			if err != nil {
-				return err
-			}
-
-			if err := afero.WriteFile(r.fs, dest, data, info.Mode()); err != nil {
-				return err
-			}
+		if err != nil {
+			return err
 		}
 
-		return nil
+		return r.writeAsset(filepath.ToSlash(strings.TrimPrefix(path, themeRoot)), data, info.Mode())
 	})
 }
 
-func (r *rendererAce) renderTopic(parentPath string, doc Documentation, topic TopicRef) error {
+// writeAsset writes an asset to the output, under a name holding a hash of
+// its content if fingerprinting is on, and records the URL it is served from.
+func (r *rendererAce) writeAsset(name string, data []byte, mode os.FileMode) error {
 
-	handle := filepath.Join(parentPath, topic.Handle)
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	file := name
 
-	if err := r.executeTemplate("topic", handle, doc, topic.Title, topic); err != nil {
-		return err
+	if ext := path.Ext(name); r.options.Fingerprint && fingerprintExtensions[ext] {
+		file = strings.TrimSuffix(name, ext) + "." + hash[:8] + ext
 	}
 
-	for _, child := range topic.Children {
-		if err := r.renderTopic(handle, doc, child); err != nil {
-			return err
-		}
+	dest := filepath.Join(r.outputPath, filepath.FromSlash(file))
+
+	r.mu.Lock()
+	r.assets[name] = "/" + file
+	r.written[dest] = hash
+	r.mu.Unlock()
+
+	if r.unchanged(r.previous.Assets, dest, hash) {
+		return nil
 	}
 
-	return nil
+	if err := r.fs.MkdirAll(filepath.Dir(dest), os.ModePerm); err != nil {
+		return err
+	}
+
+	return afero.WriteFile(r.fs, dest, data, mode)
 }
 
-func (r *rendererAce) aceTemplatePath() string {
-	return filepath.Join(r.templatePath, "ace")
+func (r *rendererAce) topicPages(parentPath string, topic TopicRef, pages *[]rendererAcePage) {
+
+	handle := filepath.Join(parentPath, topic.Handle)
+
+	*pages = append(*pages, rendererAcePage{"topic", handle, topic.Title, topic})
+
+	for _, child := range topic.Children {
+		r.topicPages(handle, child, pages)
+	}
 }
 
 func (r *rendererAce) htmlPath(path string) string {
 	return filepath.Join(r.outputPath, path) + "/index.html"
 }
 
-func (r *rendererAce) asset(file string) ([]byte, error) {
-	return afero.ReadFile(r.fs, file)
-}
-
 func (r *rendererAce) executeTemplate(src, dest string, d Documentation, title string, context interface{}) error {
 
-	tpl, err := ace.Load("master", src, &ace.Options{
-		Asset:         r.asset,
-		DynamicReload: true,
-		FuncMap:       r.templateFuncs(),
-		BaseDir:       r.aceTemplatePath(),
-	})
-
-	if err != nil {
-		return err
-	}
-
-	var buf bytes.Buffer
-
 	pageURL := "/" + dest
 	nav := r.navigation(d, pageURL)
+	previous, next := nav.pager()
 
 	args := struct {
 		Context     interface{}
+		Site        Site
 		Doc         Documentation
 		Navigation  rendererAceNavigation
+		Breadcrumbs []rendererAceNavigation
+		Previous    *rendererAceNavigation
+		Next        *rendererAceNavigation
 		SearchItems []rendererAceNavigation
 		Glossary    []TermRef
 		Title       string
 		PageURL     string
+		Canonical   string
+		Root        string
+		TOC         []Heading
 	}{
 		Doc:         d,
+		Site:        r.options.Site,
 		Context:     context,
 		Navigation:  nav,
+		Breadcrumbs: nav.breadcrumbs(),
+		Previous:    previous,
+		Next:        next,
 		SearchItems: nav.flatten(),
 		Glossary:    d.Glossary,
 		Title:       title,
 		PageURL:     pageURL,
+		Canonical:   r.canonicalURL(pageURL),
+		Root:        r.linker.root(pageURL),
+		TOC:         pageTOC(context),
 	}
 
+	hash, err := r.inputHash(src, r.docHash, args.Context, args.Navigation, args.Site, args.Title, args.PageURL, args.Canonical, r.assets, r.linker.relative, d.RootTopic.markdownOptions())
+
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.pages[r.htmlPath(dest)] = hash
+	r.mu.Unlock()
+
+	if r.unchanged(r.previous.Pages, r.htmlPath(dest), hash) {
+		return nil
+	}
+
+	tpl, err := r.template(src)
+
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
 	if err := tpl.Execute(&buf, args); err != nil {
 		return err
 	}
 
-	if err := r.fs.MkdirAll(filepath.Base(r.htmlPath(dest)), os.ModePerm); err != nil {
+	if err := r.fs.MkdirAll(filepath.Dir(r.htmlPath(dest)), os.ModePerm); err != nil {
 		return err
 	}
 
-	return afero.WriteReader(r.fs, r.htmlPath(dest), &buf)
+	return afero.WriteFile(r.fs, r.htmlPath(dest), r.linker.rewrite(pageURL, buf.Bytes()), 0644)
+}
+
+// pageTOC lists the headings of a page: those of a topic, or the terms of
+// the glossary.
+func pageTOC(context interface{}) (toc []Heading) {
+
+	switch context := context.(type) {
+	case TopicRef:
+		return context.TOC()
+
+	case []TermRef:
+		for _, term := range context {
+			toc = append(toc, Heading{Level: 3, Title: term.Title, ID: term.Handle})
+		}
+	}
+
+	return
+}
+
+// template compiles each template once per build.
+func (r *rendererAce) template(src string) (*template.Template, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tpl, ok := r.templates[src]; ok {
+		return tpl, nil
+	}
+
+	tpl, err := r.engine.Load(r.theme, src, r.templateFuncs())
+
+	if err != nil {
+		return nil, err
+	}
+
+	r.templates[src] = tpl
+
+	return tpl, nil
+}
+
+// inputHash identifies what a page is rendered from: its template, the
+// documentation, its topic, navigation and the URLs of the assets it may
+// link to.
+func (r *rendererAce) inputHash(src string, inputs ...interface{}) (string, error) {
+
+	if r.options.Cache == nil {
+		return "", nil
+	}
+
+	data, err := json.Marshal(inputs)
+
+	if err != nil {
+		return "", err
+	}
+
+	return contentKey("page", r.templateHash, []byte(src), data), nil
 }
 
 func (r *rendererAce) templateFuncs() template.FuncMap {
 	return template.FuncMap{
+		// asset returns the URL of a theme asset, such as "css/site.css",
+		// which differs from its path when fingerprinting is on.
+		"asset": func(name string) (string, error) {
+
+			if url, ok := r.assets[strings.TrimPrefix(name, "/")]; ok {
+				return url, nil
+			}
+
+			return "", fmt.Errorf("unknown asset %q", name)
+		},
+		// tagURL returns the URL of the page listing what carries a tag.
+		"tagURL": func(tag string) string {
+			return "/tags/" + TagHandle(tag)
+		},
 		"json": func(inp interface{}) template.JS {
 
 			jsn, err := json.Marshal(inp)
@@ -0,0 +1,127 @@
+package kman
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	sitemapPath = "sitemap.xml"
+	robotsPath  = "robots.txt"
+)
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// canonicalURL returns the full URL of a page, which is only known with a
+// base URL.
+func (r *rendererAce) canonicalURL(pageURL string) string {
+
+	if r.options.BaseURL == "" {
+		return ""
+	}
+
+	url := strings.TrimSuffix(r.options.BaseURL, "/") + strings.TrimSuffix(pageURL, "/")
+
+	return url + "/"
+}
+
+// lastModified returns when the sources of a page last changed.
+func (r *rendererAce) lastModified(page rendererAcePage) (last time.Time) {
+
+	if r.options.ModTime == nil {
+		return
+	}
+
+	var items []Item
+
+	switch context := page.context.(type) {
+	case TopicRef:
+		items = append(items, context.Item)
+
+	case []TermRef:
+		for _, term := range context {
+			items = append(items, term.Item)
+		}
+	}
+
+	for _, item := range items {
+		if t, ok := r.options.ModTime(item.FileName); ok && t.After(last) {
+			last = t
+		}
+	}
+
+	return
+}
+
+// writeSitemap lists every page, if the site has a base URL to list them
+// under.
+func (r *rendererAce) writeSitemap(pages []rendererAcePage) error {
+
+	if r.options.BaseURL == "" {
+		return nil
+	}
+
+	set := sitemapURLSet{
+		XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9",
+	}
+
+	for _, page := range pages {
+
+		url := sitemapURL{
+			Loc: r.canonicalURL("/" + page.dest),
+		}
+
+		if last := r.lastModified(page); !last.IsZero() {
+			url.LastMod = last.UTC().Format(time.RFC3339)
+		}
+
+		set.URLs = append(set.URLs, url)
+	}
+
+	data, err := xml.MarshalIndent(set, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return r.writeAsset(sitemapPath, append([]byte(xml.Header), append(data, '\n')...), 0644)
+}
+
+// writeRobots writes a robots.txt allowing everything but the configured
+// paths, unless the theme has its own.
+func (r *rendererAce) writeRobots() error {
+
+	if _, ok := r.assets[robotsPath]; ok {
+		return nil
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintln(&buf, "User-agent: *")
+
+	if len(r.options.Disallow) == 0 {
+		fmt.Fprintln(&buf, "Disallow:")
+	}
+
+	for _, path := range r.options.Disallow {
+		fmt.Fprintf(&buf, "Disallow: %s\n", r.linker.base+"/"+strings.TrimPrefix(path, "/"))
+	}
+
+	if r.options.BaseURL != "" {
+		fmt.Fprintf(&buf, "\nSitemap: %s\n", strings.TrimSuffix(r.options.BaseURL, "/")+"/"+sitemapPath)
+	}
+
+	return r.writeAsset(robotsPath, buf.Bytes(), 0644)
+}
@@ -1,7 +1,10 @@
 package kman
 
 import (
+	"fmt"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/endiangroup/snaptest"
 	"github.com/spf13/afero"
@@ -110,6 +113,51 @@ func Test_AnAceRendererCanAssembleTheNav(t *testing.T) {
 	snaptest.Snapshot(t, renderer.(*rendererAce).navigation(newValidDocumentation(t), "/usage/advanced"))
 }
 
+func Test_AnAceRendererShouldFindTheBreadcrumbsAndNeighboursOfAPage(t *testing.T) {
+
+	fs := newValidTemplateFilesystem(t)
+	renderer := NewRendererAce(fs, "template", "public")
+
+	urls := func(items ...*rendererAceNavigation) (output []string) {
+		for _, item := range items {
+			if item == nil {
+				output = append(output, "")
+			} else {
+				output = append(output, item.URL)
+			}
+		}
+		return
+	}
+
+	for i, c := range []struct {
+		page        string
+		breadcrumbs []string
+		previous    string
+		next        string
+	}{
+		{"/", nil, "", "/usage"},
+		{"/usage", []string{"/"}, "/", "/usage/advanced"},
+		{"/usage/advanced", []string{"/", "/usage"}, "/usage", ""},
+		{"/glossary", []string{"/"}, "", ""},
+	} {
+		t.Run(fmt.Sprintf("Cycle %d: %s", i, c.page), func(t *testing.T) {
+
+			nav := renderer.(*rendererAce).navigation(newValidDocumentation(t), c.page)
+
+			var breadcrumbs []string
+
+			for _, item := range nav.breadcrumbs() {
+				breadcrumbs = append(breadcrumbs, item.URL)
+			}
+
+			previous, next := nav.pager()
+
+			require.Equal(t, c.breadcrumbs, breadcrumbs)
+			require.Equal(t, []string{c.previous, c.next}, urls(previous, next))
+		})
+	}
+}
+
 func Test_ARendererAceCanRenderAWebsite(t *testing.T) {
 
 	fs := newValidTemplateFilesystem(t)
@@ -119,3 +167,295 @@ func Test_ARendererAceCanRenderAWebsite(t *testing.T) {
 
 	snapshotFilesystem(t, fs)
 }
+
+func Test_ARendererAceWithACacheShouldOnlyRenderChangedPages(t *testing.T) {
+
+	fs := newValidTemplateFilesystem(t)
+	cache := NewFilesystemCache(afero.NewMemMapFs(), ".kman/cache")
+	renderer := NewRendererAceWithOptions(fs, "template", "public", RendererOptions{Cache: cache})
+
+	doc := newValidDocumentation(t)
+	require.Nil(t, renderer.Render(doc))
+
+	// Pages are left alone while their inputs are unchanged.
+	require.Nil(t, afero.WriteFile(fs, "public/usage/advanced/index.html", []byte("unchanged"), os.ModePerm))
+	require.Nil(t, afero.WriteFile(fs, "public/glossary/index.html", []byte("unchanged"), os.ModePerm))
+
+	require.Nil(t, renderer.Render(newValidDocumentation(t)))
+
+	advanced, err := afero.ReadFile(fs, "public/usage/advanced/index.html")
+	require.Nil(t, err)
+	require.Equal(t, "unchanged", string(advanced))
+
+	// Every page gets the glossary and the rest of the documentation, so a
+	// change to a term renders topics again too.
+	doc.Glossary = doc.Glossary[:1]
+	require.Nil(t, renderer.Render(doc))
+
+	for _, file := range []string{"public/usage/advanced/index.html", "public/glossary/index.html"} {
+		page, err := afero.ReadFile(fs, file)
+		require.Nil(t, err)
+		require.NotEqual(t, "unchanged", string(page), file)
+	}
+
+	// Pages of removed topics are deleted.
+	doc.RootTopic.Children[0].Children = nil
+	require.Nil(t, renderer.Render(doc))
+
+	exists, err := afero.Exists(fs, "public/usage/advanced/index.html")
+	require.Nil(t, err)
+	require.False(t, exists)
+}
+
+func Test_ARendererAceShouldCopyNestedAndEmptyAssets(t *testing.T) {
+
+	fs := newValidTemplateFilesystem(t)
+	extendMockFilesystem(t, fs, map[string]string{
+		"template/fonts/icons/icons.woff": "C",
+		"template/.nojekyll":              "",
+	})
+
+	renderer := NewRendererAce(fs, "template", "public")
+	require.Nil(t, renderer.Render(newValidDocumentation(t)))
+
+	for _, file := range []string{"public/fonts/icons/icons.woff", "public/images/logo.svg", "public/.nojekyll"} {
+		exists, err := afero.Exists(fs, file)
+		require.Nil(t, err)
+		require.True(t, exists, file)
+	}
+}
+
+func Test_ARendererAceCanFingerprintAssets(t *testing.T) {
+
+	fs := newValidTemplateFilesystem(t)
+	extendMockFilesystem(t, fs, map[string]string{
+		"template/ace/index.ace": `
+= content main
+  link rel=stylesheet href="{{asset ` + "`css/site.css`" + `}}"
+`,
+		"template/css/site.css": "body {}",
+		"template/extra.txt":    "D",
+	})
+
+	cache := NewFilesystemCache(afero.NewMemMapFs(), ".kman/cache")
+	renderer := NewRendererAceWithOptions(fs, "template", "public", RendererOptions{
+		Fingerprint: true,
+		Cache:       cache,
+	})
+
+	require.Nil(t, renderer.Render(newValidDocumentation(t)))
+
+	index, err := afero.ReadFile(fs, "public/index.html")
+	require.Nil(t, err)
+	require.Contains(t, string(index), `href="/css/site.62368a1a.css"`)
+
+	for file, exists := range map[string]bool{
+		"public/css/site.62368a1a.css": true,
+		"public/css/site.css":          false,
+		"public/extra.txt":             true,
+	} {
+		found, err := afero.Exists(fs, file)
+		require.Nil(t, err)
+		require.Equal(t, exists, found, file)
+	}
+
+	// Assets the next build does not write are removed.
+	require.Nil(t, afero.WriteFile(fs, "template/css/site.css", []byte("body { margin: 0 }"), os.ModePerm))
+	require.Nil(t, fs.Remove("template/extra.txt"))
+	require.Nil(t, renderer.Render(newValidDocumentation(t)))
+
+	for _, file := range []string{"public/css/site.62368a1a.css", "public/extra.txt"} {
+		found, err := afero.Exists(fs, file)
+		require.Nil(t, err)
+		require.False(t, found, file)
+	}
+
+	index, err = afero.ReadFile(fs, "public/index.html")
+	require.Nil(t, err)
+	require.NotContains(t, string(index), "site.62368a1a.css")
+}
+
+func Test_ARendererAceWithoutACacheShouldRemoveStaleFiles(t *testing.T) {
+
+	fs := newValidTemplateFilesystem(t)
+	extendMockFilesystem(t, fs, map[string]string{
+		"template/css/site.css": "body {}",
+	})
+
+	manifests := NewFilesystemCache(fs, ".kman/cache")
+
+	renderer := NewRendererAceWithOptions(fs, "template", "public", RendererOptions{Fingerprint: true, Manifests: manifests})
+	require.Nil(t, renderer.Render(newValidDocumentation(t)))
+
+	for _, file := range []string{"public/css/site.62368a1a.css", "public/usage/advanced/index.html"} {
+		found, err := afero.Exists(fs, file)
+		require.Nil(t, err)
+		require.True(t, found, file)
+	}
+
+	// The manifest is kept out of the output, so that it isn't deployed.
+	require.True(t, manifests.Get("render:public", &rendererAceManifest{}))
+
+	hidden, err := afero.Glob(fs, "public/.*")
+	require.Nil(t, err)
+	require.Empty(t, hidden)
+
+	// Removed topics and replaced assets are removed by the next build.
+	d := newValidDocumentation(t)
+	d.RootTopic.Children[0].Children = nil
+
+	require.Nil(t, afero.WriteFile(fs, "template/css/site.css", []byte("body { margin: 0 }"), 0644))
+	require.Nil(t, renderer.Render(d))
+
+	for file, exists := range map[string]bool{
+		"public/css/site.62368a1a.css":     false,
+		"public/usage/advanced/index.html": false,
+		"public/usage/index.html":          true,
+	} {
+		found, err := afero.Exists(fs, file)
+		require.Nil(t, err)
+		require.Equal(t, exists, found, file)
+	}
+}
+
+func Test_ARendererAceShouldFailOnUnknownAssets(t *testing.T) {
+
+	fs := newValidTemplateFilesystem(t)
+	extendMockFilesystem(t, fs, map[string]string{
+		"template/ace/index.ace": `
+= content main
+  script src="{{asset ` + "`js/missing.js`" + `}}"
+`,
+	})
+
+	renderer := NewRendererAce(fs, "template", "public")
+	require.NotNil(t, renderer.Render(newValidDocumentation(t)))
+}
+
+func Test_ARendererAceWithABaseURLShouldWriteASitemap(t *testing.T) {
+
+	fs := newValidTemplateFilesystem(t)
+	require.Nil(t, fs.Remove("template/robots.txt"))
+
+	modified := time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	renderer := NewRendererAceWithOptions(fs, "template", "public", RendererOptions{
+		BaseURL:  "https://example.org/docs/",
+		Disallow: []string{"/usage/advanced"},
+		ModTime: func(fileName string) (time.Time, bool) {
+			return modified, fileName == "doc/terms.md"
+		},
+	})
+
+	require.Nil(t, renderer.Render(newValidDocumentation(t)))
+
+	sitemap, err := afero.ReadFile(fs, "public/sitemap.xml")
+	require.Nil(t, err)
+	require.Contains(t, string(sitemap), "<loc>https://example.org/docs/</loc>")
+	require.Contains(t, string(sitemap), "<loc>https://example.org/docs/usage/advanced/</loc>")
+	require.Contains(t, string(sitemap), "<loc>https://example.org/docs/glossary/</loc>\n    <lastmod>2018-03-01T12:00:00Z</lastmod>")
+
+	robots, err := afero.ReadFile(fs, "public/robots.txt")
+	require.Nil(t, err)
+	require.Equal(t, "User-agent: *\nDisallow: /docs/usage/advanced\n\nSitemap: https://example.org/docs/sitemap.xml\n", string(robots))
+}
+
+func Test_ARendererAceShouldKeepTheRobotsFileOfTheTheme(t *testing.T) {
+
+	fs := newValidTemplateFilesystem(t)
+	renderer := NewRendererAceWithOptions(fs, "template", "public", RendererOptions{
+		BaseURL: "https://example.org/",
+	})
+
+	require.Nil(t, renderer.Render(newValidDocumentation(t)))
+
+	robots, err := afero.ReadFile(fs, "public/robots.txt")
+	require.Nil(t, err)
+	require.Equal(t, "A", string(robots))
+}
+
+func Test_ARendererAceShouldListTheHeadingsOfATopic(t *testing.T) {
+
+	d := newValidDocumentation(t)
+	d.RootTopic.Content = "## Install\n\n### From source\n\n## Install"
+
+	fs := afero.NewMemMapFs()
+	renderer := NewRendererAceWithOptions(fs, "", "public", RendererOptions{Theme: BuiltinTheme()})
+	require.Nil(t, renderer.Render(d))
+
+	html, err := afero.ReadFile(fs, "public/index.html")
+	require.Nil(t, err)
+
+	for _, part := range []string{
+		`<h2 id="install">Install</h2>`,
+		`<h3 id="from-source">From source</h3>`,
+		`<h2 id="install-1">Install</h2>`,
+		`On this page`,
+		`<a href="#from-source">From source</a>`,
+		`<a href="#install-1">Install</a>`,
+	} {
+		require.Contains(t, string(html), part)
+	}
+}
+
+func Test_ARendererAceShouldWriteTagPages(t *testing.T) {
+
+	d := newValidDocumentation(t)
+	d.RootTopic.Tags = []string{"Getting started"}
+	d.Tags = []TagRef{{
+		Name:   "Getting started",
+		Handle: "getting-started",
+		Topics: []PageRef{{URL: "/", Item: d.RootTopic.Item}},
+	}}
+
+	fs := afero.NewMemMapFs()
+	renderer := NewRendererAceWithOptions(fs, "", "public", RendererOptions{Theme: BuiltinTheme()})
+	require.Nil(t, renderer.Render(d))
+
+	for file, parts := range map[string][]string{
+		"public/index.html":                      {`<a class="tag" href="/tags/getting-started">Getting started</a>`, `<a href="/tags">Tags</a>`},
+		"public/tags/index.html":                 {`<a class="tag" href="/tags/getting-started">Getting started<span class="tag-count">1</span></a>`},
+		"public/tags/getting-started/index.html": {`<h2>Getting started</h2>`, `<a href="/">k-man: intuitive documentation parser and presenter</a>`},
+	} {
+		html, err := afero.ReadFile(fs, file)
+		require.Nil(t, err)
+
+		for _, part := range parts {
+			require.Contains(t, string(html), part)
+		}
+	}
+
+	// Themes without tag templates get no tag pages.
+	fs = newValidTemplateFilesystem(t)
+	renderer = NewRendererAceWithOptions(fs, "template", "public", RendererOptions{})
+	require.Nil(t, renderer.Render(d))
+
+	exists, _ := afero.Exists(fs, "public/index.html")
+	require.True(t, exists)
+
+	exists, _ = afero.DirExists(fs, "public/tags")
+	require.False(t, exists)
+}
+
+func Test_ARendererAceShouldListReferences(t *testing.T) {
+
+	d := newValidDocumentation(t)
+	d.RootTopic.Backlinks = []PageRef{{URL: "/usage", Item: Item{Title: "Usage"}}}
+	d.Glossary = []TermRef{{
+		Item:   Item{Type: ItemTypeTerm, Title: "Node", Handle: "node"},
+		UsedIn: []PageRef{{URL: "/", Item: Item{Title: "Home"}}},
+	}}
+
+	fs := afero.NewMemMapFs()
+	renderer := NewRendererAceWithOptions(fs, "", "public", RendererOptions{Theme: BuiltinTheme()})
+	require.Nil(t, renderer.Render(d))
+
+	for file, part := range map[string]string{
+		"public/index.html":          `<h3>Referenced by</h3><ul class="references-list"><li><a href="/usage">Usage</a></li></ul>`,
+		"public/glossary/index.html": `<h4>Used in</h4><ul class="references-list"><li><a href="/">Home</a></li></ul>`,
+	} {
+		html, err := afero.ReadFile(fs, file)
+		require.Nil(t, err)
+		require.Contains(t, string(html), part)
+	}
+}
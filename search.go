@@ -0,0 +1,368 @@
+package kman
+
+import (
+	"bytes"
+	"html"
+	"html/template"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// searchTitleBoost is the weight of a word in a title, relative to one in
+// the body.
+const searchTitleBoost = 5
+
+// SearchDocument is a page, or part of a page, which can be found by search.
+type SearchDocument struct {
+	Title string   `json:"title"`
+	URL   string   `json:"url"`
+	Type  ItemType `json:"type"`
+	Text  string   `json:"text"`
+}
+
+// SearchIndex maps stemmed words to the documents containing them. It is
+// written to the rendered site as is, and the theme's search script stems
+// queries the same way.
+type SearchIndex struct {
+	Documents []SearchDocument         `json:"documents"`
+	Terms     map[string][]SearchMatch `json:"terms"`
+}
+
+// SearchMatch is the index of a document and the score of a word in it.
+type SearchMatch [2]int
+
+// SearchResult is a document found by a query, with the part of its text
+// that matched. Words matching the query are highlighted with <mark> in the
+// HTML snippet.
+type SearchResult struct {
+	Title   string        `json:"title"`
+	URL     string        `json:"url"`
+	Type    string        `json:"type"`
+	Snippet template.HTML `json:"snippet"`
+	Score   int           `json:"score"`
+}
+
+const (
+	searchSnippetBefore = 60
+	searchSnippetLength = 200
+)
+
+var searchStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "in": true, "is": true,
+	"it": true, "of": true, "on": true, "or": true, "that": true, "the": true,
+	"this": true, "to": true, "was": true, "with": true,
+}
+
+func NewSearchIndex(d Documentation) *SearchIndex {
+
+	index := &SearchIndex{
+		Terms: make(map[string][]SearchMatch),
+	}
+
+	searchDocumentsFromTopic("/", d.RootTopic, &index.Documents)
+
+	for _, term := range d.Glossary {
+		index.Documents = append(index.Documents, SearchDocument{
+			Title: term.Title,
+			URL:   "/glossary#" + term.Handle,
+			Type:  ItemTypeTerm,
+			Text:  markdownText(term.Item),
+		})
+	}
+
+	for i, doc := range index.Documents {
+
+		scores := make(map[string]int)
+
+		for _, word := range searchWords(doc.Title) {
+			scores[word] += searchTitleBoost
+		}
+
+		for _, word := range searchWords(doc.Text) {
+			scores[word]++
+		}
+
+		for word, score := range scores {
+			index.Terms[word] = append(index.Terms[word], SearchMatch{i, score})
+		}
+	}
+
+	return index
+}
+
+func searchDocumentsFromTopic(url string, topic TopicRef, docs *[]SearchDocument) {
+
+	if topic.Title != "" {
+		*docs = append(*docs, SearchDocument{
+			Title: topic.Title,
+			URL:   url,
+			Type:  ItemTypeTopic,
+			Text:  markdownText(topic.Item),
+		})
+	}
+
+	for _, child := range topic.Children {
+		searchDocumentsFromTopic(path.Join(url, child.Handle), child, docs)
+	}
+}
+
+// searchWords splits text into stemmed words, leaving out stop words.
+func searchWords(text string) (words []string) {
+
+	for _, word := range searchQueryWords(text) {
+		words = append(words, searchStem(word))
+	}
+
+	return
+}
+
+// searchQueryWords splits text into lower case words, leaving out stop
+// words, without stemming them.
+func searchQueryWords(text string) (words []string) {
+
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), searchSeparator) {
+		if !searchStopWords[word] {
+			words = append(words, word)
+		}
+	}
+
+	return
+}
+
+func searchSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// searchStem strips common English suffixes, so that "parse", "parses",
+// "parsed" and "parsing" are found by each other. It is deliberately simple,
+// as the theme's search script must stem queries identically.
+func searchStem(word string) string {
+
+	if len(word) <= 3 || strings.IndexFunc(word, func(r rune) bool { return r > unicode.MaxASCII }) >= 0 {
+		return word
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		word = word[:len(word)-3] + "y"
+
+	case strings.HasSuffix(word, "sses"):
+		word = word[:len(word)-2]
+
+	case strings.HasSuffix(word, "ing") && len(word) > 5:
+		word = word[:len(word)-3]
+
+	case strings.HasSuffix(word, "ed") && len(word) > 4:
+		word = word[:len(word)-2]
+
+	case strings.HasSuffix(word, "ly") && len(word) > 4:
+		word = word[:len(word)-2]
+
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		word = word[:len(word)-1]
+	}
+
+	// Undouble final consonants: "running" to "run", "stopped" to "stop"
+	if n := len(word); n > 3 && word[n-1] == word[n-2] && !strings.ContainsRune("aeiouslz", rune(word[n-1])) {
+		word = word[:n-1]
+	}
+
+	if n := len(word); n > 4 && word[n-1] == 'e' {
+		word = word[:n-1]
+	}
+
+	return word
+}
+
+var (
+	markdownTags   = regexp.MustCompile(`<[^>]*>`)
+	markdownSpaces = regexp.MustCompile(`\s+`)
+)
+
+// markdownText renders markdown to plain text. Code is left unhighlighted,
+// so that line numbers don't become words.
+func markdownText(item Item) string {
+
+	text := markdownTags.ReplaceAllString(string(parseMarkdown(item.Content, "", item.markdownOptions()).render(false)), " ")
+
+	return strings.TrimSpace(markdownSpaces.ReplaceAllString(html.UnescapeString(text), " "))
+}
+
+// Search returns the documents containing every word of the query, best
+// first. The last word also matches as a prefix, as queries are often typed
+// incrementally.
+func (s *SearchIndex) Search(query string) (results []SearchResult) {
+
+	words := searchQueryWords(query)
+
+	if len(words) == 0 {
+		return
+	}
+
+	var totals map[int]int
+
+	for i, word := range words {
+
+		scores := s.matches(word, i == len(words)-1)
+		next := make(map[int]int)
+
+		for doc, score := range scores {
+			if total, ok := totals[doc]; ok || totals == nil {
+				next[doc] = total + score
+			}
+		}
+
+		totals = next
+	}
+
+	for doc, score := range totals {
+		results = append(results, SearchResult{
+			Title:   s.Documents[doc].Title,
+			URL:     s.Documents[doc].URL,
+			Type:    s.Documents[doc].Type.Name(),
+			Snippet: searchSnippet(s.Documents[doc].Text, words),
+			Score:   score,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+
+		return results[i].Title < results[j].Title
+	})
+
+	return
+}
+
+// matches returns the score of a word in each document containing it.
+func (s *SearchIndex) matches(word string, prefix bool) map[int]int {
+
+	scores := make(map[int]int)
+	stem := searchStem(word)
+
+	for term, matches := range s.Terms {
+
+		if term != stem && !(prefix && strings.HasPrefix(term, word)) {
+			continue
+		}
+
+		for _, match := range matches {
+			if match[1] > scores[match[0]] {
+				scores[match[0]] = match[1]
+			}
+		}
+	}
+
+	return scores
+}
+
+// searchSnippet returns the part of the text around the first word matching
+// the query, with every matching word highlighted.
+func searchSnippet(text string, words []string) template.HTML {
+
+	stems := make(map[string]bool)
+
+	for _, word := range words {
+		stems[searchStem(word)] = true
+	}
+
+	prefix := words[len(words)-1]
+
+	matching := func(word string) bool {
+		word = strings.ToLower(word)
+		return stems[searchStem(word)] || strings.HasPrefix(word, prefix)
+	}
+
+	// Find the word boundaries, and the first matching word.
+	type span struct{ start, end int }
+
+	var spans []span
+	first := -1
+
+	for i := 0; i < len(text); {
+
+		r, size := utf8.DecodeRuneInString(text[i:])
+
+		if searchSeparator(r) {
+			i += size
+			continue
+		}
+
+		start := i
+
+		for i < len(text) {
+			r, size = utf8.DecodeRuneInString(text[i:])
+
+			if searchSeparator(r) {
+				break
+			}
+
+			i += size
+		}
+
+		if matching(text[start:i]) {
+			spans = append(spans, span{start, i})
+
+			if first < 0 {
+				first = start
+			}
+		}
+	}
+
+	start := 0
+
+	if first > searchSnippetBefore {
+		start = first - searchSnippetBefore
+
+		for start < len(text) && !utf8.RuneStart(text[start]) {
+			start++
+		}
+	}
+
+	end := start + searchSnippetLength
+
+	if end >= len(text) {
+		end = len(text)
+	} else {
+		for end > start && !utf8.RuneStart(text[end]) {
+			end--
+		}
+	}
+
+	var buf bytes.Buffer
+
+	if start > 0 {
+		buf.WriteString("&hellip;")
+	}
+
+	pos := start
+
+	for _, s := range spans {
+
+		if s.end <= start || s.start >= end {
+			continue
+		}
+
+		buf.WriteString(html.EscapeString(text[pos:s.start]))
+		buf.WriteString("<mark>" + html.EscapeString(text[s.start:s.end]) + "</mark>")
+		pos = s.end
+	}
+
+	if pos < end {
+		buf.WriteString(html.EscapeString(text[pos:end]))
+	}
+
+	if end < len(text) {
+		buf.WriteString("&hellip;")
+	}
+
+	return template.HTML(buf.String())
+}
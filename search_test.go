@@ -0,0 +1,85 @@
+package kman
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/endiangroup/snaptest"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SearchStemmingShouldFindRelatedWords(t *testing.T) {
+
+	for _, words := range [][]string{
+		{"parse", "parses", "parsed", "parsing"},
+		{"topic", "topics"},
+		{"glossary", "glossaries"},
+		{"run", "running", "runs"},
+		{"stop", "stopped", "stops"},
+		{"class", "classes"},
+		{"address", "addressed"},
+	} {
+		for _, word := range words[1:] {
+			require.Equal(t, searchStem(words[0]), searchStem(word), word)
+		}
+	}
+
+	require.Equal(t, "status", searchStem("status"))
+	require.Equal(t, "straße", searchStem("straße"))
+}
+
+func Test_SearchWordsShouldSkipStopWordsAndPunctuation(t *testing.T) {
+	require.Equal(t, []string{"pars", "markdown", "go", "file"}, searchWords("Parsing the Markdown, and Go files!"))
+}
+
+func Test_ASearchIndexShouldCoverTopicsAndTerms(t *testing.T) {
+
+	index := NewSearchIndex(newValidDocumentation(t))
+
+	require.Len(t, index.Documents, 5)
+	require.Equal(t, "/usage/advanced", index.Documents[2].URL)
+	require.Equal(t, "/glossary#another_example", index.Documents[3].URL)
+
+	// Title words outweigh body words.
+	require.Equal(t, []SearchMatch{
+		SearchMatch{1, searchTitleBoost},
+		SearchMatch{2, searchTitleBoost + 1},
+	}, index.Terms["usag"])
+
+	snaptest.Snapshot(t, index)
+}
+
+func Test_ASearchIndexShouldFindDocumentsMatchingEveryWord(t *testing.T) {
+
+	index := NewSearchIndex(newValidDocumentation(t))
+
+	results := index.Search("examples")
+
+	require.Len(t, results, 3)
+	require.Equal(t, "Another example", results[0].Title)
+	require.Equal(t, "term", results[0].Type)
+	require.Equal(t, "/glossary#another_example", results[0].URL)
+	require.Equal(t, template.HTML("Another markdown-parsed <mark>example</mark>"), results[0].Snippet)
+	require.Equal(t, "Example", results[1].Title)
+	require.Equal(t, "topic", results[2].Type)
+
+	// The last word is matched as a prefix.
+	results = index.Search("usage adv")
+
+	require.Len(t, results, 1)
+	require.Equal(t, "/usage/advanced", results[0].URL)
+
+	require.Empty(t, index.Search("example missing"))
+	require.Empty(t, index.Search("the"))
+}
+
+func Test_ASearchSnippetShouldBeCutAroundTheFirstMatch(t *testing.T) {
+
+	text := strings.Repeat("lorem ipsum ", 20) + "needle <b> " + strings.Repeat("dolor sit ", 30)
+	snippet := string(searchSnippet(text, []string{"needles"}))
+
+	require.True(t, strings.HasPrefix(snippet, "&hellip;"))
+	require.True(t, strings.HasSuffix(snippet, "&hellip;"))
+	require.Contains(t, snippet, "<mark>needle</mark> &lt;b&gt;")
+}
@@ -1,6 +1,7 @@
 package kman
 
 import (
+	"path"
 	"sort"
 	"strings"
 )
@@ -35,6 +36,7 @@ func (s *sorter) Sort(input []Item) Documentation {
 
 	doc.RootTopic = s.sortItemsToTopicTree(topicItems)
 	doc.Glossary = s.sortItemsToGlossary(termItems)
+	doc.Tags = s.indexTags(doc)
 
 	return doc
 }
@@ -148,3 +150,54 @@ func (s *sorter) sortItemsToGlossary(input []Item) (output []TermRef) {
 
 	return
 }
+
+func (s *sorter) indexTags(doc Documentation) (tags []TagRef) {
+
+	index := map[string]*TagRef{}
+
+	tag := func(name string) *TagRef {
+
+		handle := TagHandle(name)
+
+		if index[handle] == nil {
+			index[handle] = &TagRef{Name: name, Handle: handle}
+		}
+
+		return index[handle]
+	}
+
+	var walk func(url string, topic TopicRef)
+
+	walk = func(url string, topic TopicRef) {
+
+		for _, name := range topic.Tags {
+			if t := tag(name); !t.has(url) {
+				t.Topics = append(t.Topics, PageRef{URL: url, Item: topic.Item})
+			}
+		}
+
+		for _, child := range topic.Children {
+			walk(path.Join(url, child.Handle), child)
+		}
+	}
+
+	walk("/", doc.RootTopic)
+
+	for _, term := range doc.Glossary {
+		for _, name := range term.Tags {
+			if t, url := tag(name), "/glossary#"+term.Handle; !t.has(url) {
+				t.Terms = append(t.Terms, PageRef{URL: url, Item: term.Item})
+			}
+		}
+	}
+
+	for _, t := range index {
+		tags = append(tags, *t)
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Handle < tags[j].Handle
+	})
+
+	return
+}
@@ -126,3 +126,15 @@ func Test_ADefaultSortedCanSortMixedItemsIntoDocumentation(t *testing.T) {
 		Item{Type: ItemTypeTerm, Handle: "D", Title: "D"},
 	}))
 }
+
+func Test_ADefaultSorterShouldIndexTags(t *testing.T) {
+
+	sorter := &sorter{}
+
+	snaptest.Snapshot(t, sorter.Sort([]Item{
+		Item{Type: ItemTypeTopic, Handle: "root", Title: "Root", Tags: []string{"Staking"}},
+		Item{Type: ItemTypeTopic, Handle: "nodes", Title: "Nodes", Tags: []string{"nodes", "staking"}},
+		Item{Type: ItemTypeTopic, Handle: "nodes_validator", Title: "Validator", Tags: []string{"Staking", "staking"}},
+		Item{Type: ItemTypeTerm, Handle: "stake", Title: "Stake", Tags: []string{"staking"}},
+	}).Tags)
+}
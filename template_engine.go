@@ -0,0 +1,62 @@
+package kman
+
+import (
+	"fmt"
+	"html/template"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	TemplateEngineAce  = "ace"
+	TemplateEngineHTML = "html"
+)
+
+// TemplateEngine compiles the page templates of a theme. Whatever the
+// engine, pages are executed with the same context.
+type TemplateEngine interface {
+
+	// Load compiles the page template name, such as "topic", within the
+	// layout of the theme.
+	Load(theme afero.Fs, name string, funcs template.FuncMap) (*template.Template, error)
+
+	// Template reports whether a theme file is a template, rather than an
+	// asset to copy to the site.
+	Template(path string) bool
+
+	// Has reports whether the theme has the page template name, so that
+	// optional pages can be left out of themes without them.
+	Has(theme afero.Fs, name string) bool
+}
+
+// NewTemplateEngine returns the engine declared by the theme config, or else
+// the html/template engine for themes with an html directory, and the Ace
+// engine for any other.
+func NewTemplateEngine(theme afero.Fs) (TemplateEngine, error) {
+
+	config, err := readThemeConfig(theme)
+
+	if err != nil {
+		return nil, err
+	}
+
+	engine := config.Engine
+
+	if engine == "" {
+		engine = TemplateEngineAce
+
+		if exists, _ := afero.DirExists(theme, templateEngineHTMLPath); exists {
+			engine = TemplateEngineHTML
+		}
+	}
+
+	switch engine {
+	case TemplateEngineAce:
+		return NewAceTemplateEngine(), nil
+
+	case TemplateEngineHTML:
+		return NewHTMLTemplateEngine(), nil
+	}
+
+	return nil, fmt.Errorf("unknown template engine %q", engine)
+}
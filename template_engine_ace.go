@@ -0,0 +1,45 @@
+package kman
+
+import (
+	"html/template"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"github.com/yosssi/ace"
+)
+
+const templateEngineAcePath = "/ace"
+
+type templateEngineAce struct{}
+
+// NewAceTemplateEngine loads Ace templates from the ace directory of a
+// theme, each page within ace/master.ace.
+func NewAceTemplateEngine() TemplateEngine {
+	return &templateEngineAce{}
+}
+
+// Load bypasses Ace's own cache, which is shared by the whole process and
+// keyed by template name alone, so that two themes, or a theme changed while
+// serving, don't get each other's templates. Renderers keep the templates they
+// load for the length of a build.
+func (e *templateEngineAce) Load(theme afero.Fs, name string, funcs template.FuncMap) (*template.Template, error) {
+	return ace.Load("master", name, &ace.Options{
+		Asset: func(file string) ([]byte, error) {
+			return afero.ReadFile(theme, file)
+		},
+		DynamicReload: true,
+		FuncMap:       funcs,
+		BaseDir:       templateEngineAcePath,
+	})
+}
+
+func (e *templateEngineAce) Template(path string) bool {
+	return filepath.Ext(path) == ".ace"
+}
+
+func (e *templateEngineAce) Has(theme afero.Fs, name string) bool {
+
+	exists, _ := afero.Exists(theme, filepath.Join(templateEngineAcePath, name+".ace"))
+
+	return exists
+}
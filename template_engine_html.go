@@ -0,0 +1,91 @@
+package kman
+
+import (
+	"html/template"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+const templateEngineHTMLPath = "/html"
+
+type templateEngineHTML struct{}
+
+// NewHTMLTemplateEngine loads Go html/template templates from the html
+// directory of a theme:
+//
+//	html/layouts/default.html   the layout of every page
+//	html/layouts/{page}.html    the layout of one page, if present
+//	html/partials/{name}.html   templates available to all, as {{template "name" .}}
+//	html/{page}.html            the page, defining the blocks of its layout
+func NewHTMLTemplateEngine() TemplateEngine {
+	return &templateEngineHTML{}
+}
+
+func (e *templateEngineHTML) Load(theme afero.Fs, name string, funcs template.FuncMap) (*template.Template, error) {
+
+	layout := path.Join(templateEngineHTMLPath, "layouts", name+".html")
+
+	if exists, _ := afero.Exists(theme, layout); !exists {
+		layout = path.Join(templateEngineHTMLPath, "layouts", "default.html")
+	}
+
+	tpl, err := e.parse(template.New("layout").Funcs(funcs), theme, layout)
+
+	if err != nil {
+		return nil, err
+	}
+
+	partials := path.Join(templateEngineHTMLPath, "partials")
+
+	err = afero.Walk(theme, partials, func(file string, info os.FileInfo, err error) error {
+
+		if os.IsNotExist(err) && file == partials {
+			return nil
+		}
+
+		if err != nil || info.IsDir() || path.Ext(file) != ".html" {
+			return err
+		}
+
+		name := strings.TrimSuffix(strings.TrimPrefix(file, partials+"/"), ".html")
+
+		_, err = e.parse(tpl.New(name), theme, file)
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := e.parse(tpl.New(name), theme, path.Join(templateEngineHTMLPath, name+".html")); err != nil {
+		return nil, err
+	}
+
+	return tpl, nil
+}
+
+func (e *templateEngineHTML) parse(tpl *template.Template, theme afero.Fs, file string) (*template.Template, error) {
+
+	data, err := afero.ReadFile(theme, file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tpl.Parse(string(data))
+}
+
+func (e *templateEngineHTML) Template(file string) bool {
+	return strings.HasPrefix(file, templateEngineHTMLPath+"/")
+}
+
+func (e *templateEngineHTML) Has(theme afero.Fs, name string) bool {
+
+	exists, _ := afero.Exists(theme, path.Join(templateEngineHTMLPath, name+".html"))
+
+	return exists
+}
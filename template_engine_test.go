@@ -0,0 +1,77 @@
+package kman
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func newValidHTMLTemplateFilesystem(t *testing.T) afero.Fs {
+	return newMockFilesystem(t,
+		map[string]string{
+			"template/html/layouts/default.html": `<!DOCTYPE html>
+<html><head><title>{{.Title}} - {{.Site.Title}}</title></head>
+<body>{{template "menu" .}}{{block "main" .}}{{end}}</body></html>`,
+			"template/html/layouts/glossary.html": `<glossary>{{block "main" .}}{{end}}</glossary>`,
+			"template/html/partials/menu.html":    `<nav>{{range .Navigation.Children}}<a href="{{.URL}}">{{.Title}}</a>{{end}}</nav>`,
+			"template/html/index.html":            `{{define "main"}}<h1>Index</h1>{{.Context.HTML}}{{end}}`,
+			"template/html/topic.html":            `{{define "main"}}<h1>{{.Context.Title}}</h1><p>{{.PageURL}}</p>{{end}}`,
+			"template/html/glossary.html":         `{{define "main"}}{{range .Glossary}}<dt>{{.Title}}</dt>{{end}}{{end}}`,
+			"template/css/site.css":               "body {}",
+		},
+	)
+}
+
+func Test_ATemplateEngineShouldBeChosenByTheTheme(t *testing.T) {
+
+	for i, c := range []struct {
+		description string
+		files       map[string]string
+		engine      TemplateEngine
+		err         bool
+	}{
+		{"Ace by default", map[string]string{"/ace/master.ace": ""}, NewAceTemplateEngine(), false},
+		{"html/template for an html directory", map[string]string{"/html/index.html": ""}, NewHTMLTemplateEngine(), false},
+		{"Declared in the theme config", map[string]string{"/html/index.html": "", "/theme.yaml": "engine: ace"}, NewAceTemplateEngine(), false},
+		{"Unknown engine", map[string]string{"/theme.yaml": "engine: other"}, nil, true},
+	} {
+		t.Run(fmt.Sprintf("Cycle %d: %s", i, c.description), func(t *testing.T) {
+
+			engine, err := NewTemplateEngine(newMockFilesystem(t, c.files))
+
+			if c.err {
+				require.NotNil(t, err)
+				return
+			}
+
+			require.Nil(t, err)
+			require.Equal(t, c.engine, engine)
+		})
+	}
+}
+
+func Test_ARendererWithAnHTMLTemplateThemeCanRenderAWebsite(t *testing.T) {
+
+	fs := newValidHTMLTemplateFilesystem(t)
+	renderer := NewRendererAce(fs, "template", "public")
+
+	require.Nil(t, renderer.Render(newValidDocumentation(t)))
+
+	for file, content := range map[string]string{
+		"public/index.html":                `<h1>Index</h1><p>This is an example topic which forms the root</p>`,
+		"public/usage/advanced/index.html": `<title>Usage: advanced - K-man docs</title>`,
+		"public/usage/index.html":          `<nav><a href="/usage">Usage</a><a href="/glossary">Glossary</a></nav><h1>Usage</h1><p>/usage</p>`,
+		"public/glossary/index.html":       `<glossary><dt>Another example</dt><dt>Example</dt></glossary>`,
+		"public/css/site.css":              "body {}",
+	} {
+		data, err := afero.ReadFile(fs, file)
+		require.Nil(t, err, file)
+		require.Contains(t, string(data), content, file)
+	}
+
+	exists, err := afero.Exists(fs, "public/html/index.html")
+	require.Nil(t, err)
+	require.False(t, exists)
+}
@@ -0,0 +1,41 @@
+package kman
+
+import "time"
+
+// Tester runs the examples of the documentation, and reports those which
+// fail.
+type Tester interface {
+	Test(Documentation) []Problem
+}
+
+// DefaultExampleTimeout is how long an example may run, if the options
+// don't say.
+const DefaultExampleTimeout = 2 * time.Minute
+
+// TesterOptions tells a tester where the project is. The zero value runs
+// examples in the working directory.
+type TesterOptions struct {
+	// Dir is where shell commands run, and the module Go examples are
+	// compiled against if it holds a go.mod, or the project if it is in
+	// GOPATH.
+	Dir     string
+	Timeout time.Duration
+}
+
+func (o TesterOptions) dir() string {
+
+	if o.Dir == "" {
+		return "."
+	}
+
+	return o.Dir
+}
+
+func (o TesterOptions) timeout() time.Duration {
+
+	if o.Timeout <= 0 {
+		return DefaultExampleTimeout
+	}
+
+	return o.Timeout
+}
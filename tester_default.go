@@ -0,0 +1,452 @@
+package kman
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+var (
+	goPackageClause = regexp.MustCompile(`(?m)^package\s+\w+`)
+	goMainPackage   = regexp.MustCompile(`(?m)^package\s+main\b`)
+	goMainFunc      = regexp.MustCompile(`(?m)^func\s+main\(\)`)
+	goTestFunc      = regexp.MustCompile(`(?m)^func\s+(Test|Example|Benchmark)\w*\(`)
+	goModuleClause  = regexp.MustCompile(`(?m)^module\s+"?([^\s"]+)"?`)
+	goVersionClause = regexp.MustCompile(`(?m)^go\s+\S+`)
+)
+
+// shellLanguages are the languages of fenced code blocks run as shell
+// transcripts.
+var shellLanguages = map[string]bool{
+	"sh":      true,
+	"shell":   true,
+	"bash":    true,
+	"console": true,
+}
+
+type testerDefault struct {
+	options TesterOptions
+}
+
+func NewDefaultTester(options TesterOptions) Tester {
+	return &testerDefault{options: options}
+}
+
+func (t *testerDefault) Test(d Documentation) (problems []Problem) {
+
+	for _, example := range d.Examples() {
+
+		err := t.run(example)
+
+		if err == nil {
+			continue
+		}
+
+		problem := Problem{
+			FileName: example.Item.FileName,
+			Line:     example.Item.Line,
+			Message:  fmt.Sprintf("%s %q, example at line %d: %s", example.Item.Type.Name(), example.Item.Title, example.Line, err),
+		}
+
+		if example.inFile {
+			problem.Line = example.Line
+			problem.Message = fmt.Sprintf("%s %q, example: %s", example.Item.Type.Name(), example.Item.Title, err)
+		}
+
+		problems = append(problems, problem)
+	}
+
+	return
+}
+
+func (t *testerDefault) run(example Example) error {
+
+	switch {
+	case example.Mode == ExampleModeTest && example.Language == "go":
+		return t.runGo(example.Code)
+
+	case example.Mode == ExampleModeExpect && shellLanguages[example.Language]:
+		return t.runTranscript(example.Code)
+	}
+
+	return fmt.Errorf("%s examples can't be run in %s mode", example.Language, example.Mode)
+}
+
+// runGo writes a snippet to a module of its own, which requires the local
+// module if there is one, then runs it if it is a program, its tests if it
+// has any, or else builds it. A snippet without a package clause is put in
+// package main.
+func (t *testerDefault) runGo(code string) error {
+
+	dir, err := ioutil.TempDir("", "kman-example")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(dir)
+
+	if !goPackageClause.MatchString(code) {
+		code = "package main\n\n" + code
+	}
+
+	flags, err := t.writeGoModule(dir, code)
+
+	if err != nil {
+		return err
+	}
+
+	file, args := "example.go", append([]string{"build", "-mod=mod", "-o", os.DevNull}, flags...)
+
+	switch {
+	case goTestFunc.MatchString(code):
+		file, args = "example_test.go", append([]string{"test", "-mod=mod"}, flags...)
+
+	case goMainPackage.MatchString(code) && goMainFunc.MatchString(code):
+		args = append([]string{"run", "-mod=mod"}, flags...)
+	}
+
+	args = append(args, ".")
+
+	if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(code+"\n"), 0644); err != nil {
+		return err
+	}
+
+	if output, err := t.command(dir, "go", args...); err != nil {
+		return fmt.Errorf("go %s: %s\n%s", args[0], err, strings.TrimRight(output, "\n"))
+	}
+
+	return nil
+}
+
+// writeGoModule writes the go.mod of an example, replacing the local module
+// by its directory, and copies its go.sum. It returns the flags the go
+// command needs to build the example.
+func (t *testerDefault) writeGoModule(dir, code string) ([]string, error) {
+
+	root, err := filepath.Abs(t.options.dir())
+
+	if err != nil {
+		return nil, err
+	}
+
+	mod, flags := "module kman.example\n", []string(nil)
+	local, err := ioutil.ReadFile(filepath.Join(root, "go.mod"))
+
+	switch {
+	case err == nil:
+
+		if version := goVersionClause.Find(local); version != nil {
+			mod += "\n" + string(version) + "\n"
+		}
+
+		if m := goModuleClause.FindSubmatch(local); m != nil {
+			mod += fmt.Sprintf("\nrequire %s v0.0.0\n\nreplace %s => %s\n", m[1], m[1], root)
+		}
+
+		if sum, err := ioutil.ReadFile(filepath.Join(root, "go.sum")); err == nil {
+			if err := ioutil.WriteFile(filepath.Join(dir, "go.sum"), sum, 0644); err != nil {
+				return nil, err
+			}
+		}
+
+	case !os.IsNotExist(err):
+		return nil, err
+
+	default:
+
+		modules, revisions, err := t.gopathModules(root)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if modules == nil {
+
+			if importsNonStandard(code) {
+				return nil, fmt.Errorf("no go.mod in %s, and it is outside GOPATH: can't resolve the local module", root)
+			}
+
+			break
+		}
+
+		overlay, err := writeModuleOverlay(dir, modules)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range sortedKeys(modules) {
+			mod += fmt.Sprintf("\nrequire %s v0.0.0\n\nreplace %s => %s\n", path, path, modules[path])
+		}
+
+		for _, path := range sortedKeys(revisions) {
+			mod += fmt.Sprintf("\nrequire %s %s\n", path, revisions[path])
+		}
+
+		flags = []string{"-overlay=" + overlay}
+	}
+
+	return flags, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(mod), 0644)
+}
+
+// gopathModules returns the directories of the modules making up a project
+// without a go.mod, by import path: the project itself, named after its
+// place in GOPATH, and the projects vendored in it. Projects locked in its
+// Gopkg.lock but not vendored are returned with their revisions, so that the
+// go command fetches those. It returns nil if the project is outside GOPATH.
+func (t *testerDefault) gopathModules(root string) (modules, revisions map[string]string, err error) {
+
+	output, err := t.command(root, "go", "env", "GOPATH")
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("go env: %s\n%s", err, strings.TrimRight(output, "\n"))
+	}
+
+	for _, gopath := range filepath.SplitList(strings.TrimSpace(output)) {
+
+		rel, err := filepath.Rel(filepath.Join(gopath, "src"), root)
+
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+
+		modules := map[string]string{filepath.ToSlash(rel): root}
+
+		if err := vendoredModules(filepath.Join(root, "vendor"), modules); err != nil {
+			return nil, nil, err
+		}
+
+		revisions, err := lockedRevisions(filepath.Join(root, "Gopkg.lock"), modules)
+
+		return modules, revisions, err
+	}
+
+	return nil, nil, nil
+}
+
+// lockedRevisions returns the revisions of the projects in a dep lock file
+// which aren't among modules.
+func lockedRevisions(path string, modules map[string]string) (map[string]string, error) {
+
+	var lock struct {
+		Projects []struct {
+			Name     string
+			Revision string
+		}
+	}
+
+	if _, err := toml.DecodeFile(path, &lock); err != nil {
+
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	revisions := make(map[string]string)
+
+	for _, project := range lock.Projects {
+		if _, ok := modules[project.Name]; !ok && project.Revision != "" {
+			revisions[project.Name] = project.Revision
+		}
+	}
+
+	return revisions, nil
+}
+
+// vendoredModules adds the projects vendored in dir to modules. A project is
+// the first directory holding Go files down each path, as tools such as dep
+// vendor whole projects, but may leave out the packages which aren't used.
+func vendoredModules(dir string, modules map[string]string) error {
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			return nil
+		}
+
+		if path != dir && (info.Name() == "vendor" || info.Name() == "testdata" || strings.HasPrefix(info.Name(), ".") || strings.HasPrefix(info.Name(), "_")) {
+			return filepath.SkipDir
+		}
+
+		files, err := filepath.Glob(filepath.Join(path, "*.go"))
+
+		if err != nil || len(files) == 0 || path == dir {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+
+		if err != nil {
+			return err
+		}
+
+		modules[filepath.ToSlash(rel)] = path
+
+		return filepath.SkipDir
+	})
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// writeModuleOverlay writes a go.mod for each module, for the go command to
+// read through an overlay in place of any go.mod of their own, and returns
+// the path of the overlay.
+func writeModuleOverlay(dir string, modules map[string]string) (string, error) {
+
+	overlay := struct{ Replace map[string]string }{Replace: make(map[string]string)}
+
+	for i, path := range sortedKeys(modules) {
+
+		file := filepath.Join(dir, "modules", strconv.Itoa(i)+".mod")
+
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return "", err
+		}
+
+		if err := ioutil.WriteFile(file, []byte("module "+path+"\n"), 0644); err != nil {
+			return "", err
+		}
+
+		overlay.Replace[filepath.Join(modules[path], "go.mod")] = file
+	}
+
+	data, err := json.Marshal(overlay)
+
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, "modules", "overlay.json")
+
+	return path, ioutil.WriteFile(path, data, 0644)
+}
+
+// importsNonStandard reports whether Go code imports packages from outside
+// the standard library, whose paths start with a domain name.
+func importsNonStandard(code string) bool {
+
+	file, err := parser.ParseFile(token.NewFileSet(), "", code, parser.ImportsOnly)
+
+	if err != nil {
+		return false
+	}
+
+	for _, spec := range file.Imports {
+		if path, err := strconv.Unquote(spec.Path.Value); err == nil && strings.Contains(strings.SplitN(path, "/", 2)[0], ".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortedKeys(m map[string]string) (keys []string) {
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return
+}
+
+// transcriptStep is a command of a shell transcript, and the output shown
+// below it.
+type transcriptStep struct {
+	command string
+	output  []string
+}
+
+func parseTranscript(code string) (steps []transcriptStep) {
+
+	for _, line := range strings.Split(code, "\n") {
+
+		line = strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(line, "$ "):
+			steps = append(steps, transcriptStep{command: strings.TrimSpace(line[2:])})
+
+		case len(steps) > 0 && line != "":
+			steps[len(steps)-1].output = append(steps[len(steps)-1].output, line)
+		}
+	}
+
+	return
+}
+
+// runTranscript runs the commands of a transcript in turn, each in a shell of
+// its own, stopping at the first which fails or whose output differs from
+// the transcript. Blank lines and indentation are ignored.
+func (t *testerDefault) runTranscript(code string) error {
+
+	steps := parseTranscript(code)
+
+	if len(steps) == 0 {
+		return fmt.Errorf("no commands found, they start with \"$ \"")
+	}
+
+	for _, step := range steps {
+
+		output, err := t.command(t.options.dir(), "sh", "-c", step.command)
+
+		if err != nil {
+			return fmt.Errorf("$ %s: %s\n%s", step.command, err, strings.TrimRight(output, "\n"))
+		}
+
+		var got []string
+
+		for _, line := range strings.Split(output, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				got = append(got, line)
+			}
+		}
+
+		if strings.Join(got, "\n") != strings.Join(step.output, "\n") {
+			return fmt.Errorf("$ %s: expected\n%s\ngot\n%s", step.command, strings.Join(step.output, "\n"), strings.Join(got, "\n"))
+		}
+	}
+
+	return nil
+}
+
+func (t *testerDefault) command(dir, name string, args ...string) (string, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.options.timeout())
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+
+	output, err := cmd.CombinedOutput()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", t.options.timeout())
+	}
+
+	return string(output), err
+}
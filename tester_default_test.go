@@ -0,0 +1,181 @@
+package kman
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ADefaultTesterShouldRunExamples(t *testing.T) {
+
+	for i, c := range []struct {
+		description string
+		content     string
+		problem     string
+	}{
+		{
+			"Transcript",
+			"```sh expect\n$ echo hello\nhello\n\n$ printf 'a\\n  b\\n'\na\nb\n```",
+			"",
+		},
+		{
+			"Transcript with other output",
+			"```sh expect\n$ echo hello\nhi\n```",
+			"topics.md: topic \"Start\", example at line 1: $ echo hello: expected\nhi\ngot\nhello",
+		},
+		{
+			"Failing command",
+			"```sh expect\n$ echo oops; exit 3\n```",
+			"topics.md: topic \"Start\", example at line 1: $ echo oops; exit 3: exit status 3\noops",
+		},
+		{
+			"Transcript without commands",
+			"```sh expect\nhello\n```",
+			"topics.md: topic \"Start\", example at line 1: no commands found, they start with \"$ \"",
+		},
+		{
+			"Unknown language",
+			"Text\n\n```ruby test\nputs 1\n```",
+			"topics.md: topic \"Start\", example at line 3: ruby examples can't be run in test mode",
+		},
+		{
+			"Go program",
+			"```go test\nimport \"fmt\"\n\nfunc main() {\nfmt.Println(\"hello\")\n}\n```",
+			"",
+		},
+		{
+			"Go test",
+			"```go test\npackage example\n\nimport \"testing\"\n\nfunc TestSum(t *testing.T) {\nif 1+1 != 2 {\nt.Fatal(\"sum\")\n}\n}\n```",
+			"",
+		},
+		{
+			"Go snippet which doesn't compile",
+			"```go test\nfunc main() {\nundefined()\n}\n```",
+			"topics.md: topic \"Start\", example at line 1: go run: exit status 1",
+		},
+	} {
+		t.Run(fmt.Sprintf("Cycle %d: %s", i, c.description), func(t *testing.T) {
+
+			d := Documentation{
+				RootTopic: TopicRef{Item: Item{Title: "Start", FileName: "topics.md", Content: c.content}},
+			}
+
+			dir, err := ioutil.TempDir("", "kman-test")
+			require.Nil(t, err)
+			defer os.RemoveAll(dir)
+
+			problems := NewDefaultTester(TesterOptions{Dir: dir}).Test(d)
+
+			if c.problem == "" {
+				require.Empty(t, problems)
+				return
+			}
+
+			require.Len(t, problems, 1)
+			require.True(t, strings.HasPrefix(problems[0].String(), c.problem), problems[0].String())
+		})
+	}
+}
+
+func Test_ADefaultTesterShouldReportTheLineOfExamplesInTheirFile(t *testing.T) {
+
+	fs := newMockFilesystem(t, map[string]string{
+		"docs/greeting.txt": "one\ntwo\nthree",
+		"docs/start.md": strings.Join([]string{
+			"Notes above the topic",
+			"",
+			"Topic: Start",
+			"Tags: greetings",
+			"",
+			"{{< include \"greeting.txt\" >}}",
+			"",
+			"{{< if audience=\"internal\" >}}",
+			"Left out",
+			"{{< end >}}",
+			"",
+			"```sh expect",
+			"$ echo hello",
+			"hi",
+			"```",
+		}, "\n"),
+	})
+
+	documenter := NewDefaultDocumenterWithOptions(NewDefaultSorter(), DocumenterOptions{Audience: []string{"public"}},
+		NewMarkdownAssemblerWithOptions(fs, AssemblerOptions{Root: "docs"}))
+
+	d, err := documenter.Document()
+	require.Nil(t, err)
+
+	dir, err := ioutil.TempDir("", "kman-test")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	problems := NewDefaultTester(TesterOptions{Dir: dir}).Test(d)
+
+	require.Len(t, problems, 1)
+	require.Equal(t, "docs/start.md:12: topic \"Start\", example: $ echo hello: expected\nhi\ngot\nhello", problems[0].String())
+}
+
+func Test_ADefaultTesterShouldCompileGoExamplesAgainstAProjectInGOPATH(t *testing.T) {
+
+	gopath, err := ioutil.TempDir("", "kman-gopath")
+	require.Nil(t, err)
+	defer os.RemoveAll(gopath)
+
+	root := filepath.Join(gopath, "src", "example.com", "project")
+
+	for path, content := range map[string]string{
+		"greet.go":                               "package project\n\nimport \"example.org/words\"\n\nfunc Greeting() string { return words.Hello }\n",
+		"vendor/example.org/words/words.go":      "package words\n\nconst Hello = \"hello from vendor\"\n",
+		"vendor/example.org/words/words_test.go": "package words\n",
+		"Gopkg.lock":                             "[[projects]]\n  name = \"example.org/words\"\n  revision = \"0123abcd\"\n",
+	} {
+		require.Nil(t, os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0755))
+		require.Nil(t, ioutil.WriteFile(filepath.Join(root, path), []byte(content), 0644))
+	}
+
+	// Nothing may come from the network: the project and its vendored
+	// dependencies are local.
+	for name, value := range map[string]string{"GOPATH": gopath, "GOPROXY": "off"} {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Setenv(name, value)
+	}
+
+	d := Documentation{
+		RootTopic: TopicRef{Item: Item{Title: "Start", FileName: "topics.md", Content: "```go test\nimport (\n\"fmt\"\n\n\"example.com/project\"\n)\n\nfunc main() {\nif project.Greeting() != \"hello from vendor\" {\npanic(project.Greeting())\n}\nfmt.Println(\"ok\")\n}\n```"}},
+	}
+
+	require.Empty(t, NewDefaultTester(TesterOptions{Dir: root}).Test(d))
+
+	outside, err := ioutil.TempDir("", "kman-test")
+	require.Nil(t, err)
+	defer os.RemoveAll(outside)
+
+	problems := NewDefaultTester(TesterOptions{Dir: outside}).Test(d)
+
+	require.Len(t, problems, 1)
+	require.Contains(t, problems[0].String(), "no go.mod in "+outside+", and it is outside GOPATH: can't resolve the local module")
+}
+
+func Test_ADefaultTesterShouldPinTheProjectsLockedButNotVendored(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "kman-test")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	lock := "[[projects]]\n  name = \"example.org/words\"\n  revision = \"0123abcd\"\n\n[[projects]]\n  name = \"example.net/other\"\n  revision = \"4567cdef\"\n  version = \"v1.2.0\"\n"
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "Gopkg.lock"), []byte(lock), 0644))
+
+	revisions, err := lockedRevisions(filepath.Join(dir, "Gopkg.lock"), map[string]string{"example.org/words": "vendor/example.org/words"})
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{"example.net/other": "4567cdef"}, revisions)
+
+	revisions, err = lockedRevisions(filepath.Join(dir, "missing.lock"), nil)
+	require.Nil(t, err)
+	require.Nil(t, revisions)
+}
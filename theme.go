@@ -0,0 +1,383 @@
+package kman
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"embed"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// themeRoot is where a loaded theme's files are found.
+const themeRoot = "/"
+
+//go:embed themes/kman
+var builtinThemeFiles embed.FS
+
+const builtinThemePath = "themes/kman"
+
+// BuiltinTheme returns the default theme, as compiled into the binary.
+func BuiltinTheme() afero.Fs {
+
+	theme := afero.NewMemMapFs()
+
+	err := iofs.WalkDir(builtinThemeFiles, builtinThemePath, func(file string, entry iofs.DirEntry, err error) error {
+
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		data, err := builtinThemeFiles.ReadFile(file)
+
+		if err != nil {
+			return err
+		}
+
+		return writeThemeFile(theme, strings.TrimPrefix(file, builtinThemePath), data)
+	})
+
+	// The embedded files are fixed at compile time, so this cannot fail at
+	// run time if it passed the tests.
+	if err != nil {
+		panic(err)
+	}
+
+	return theme
+}
+
+// BuiltinThemeName names the built-in theme as the parent of another theme.
+const BuiltinThemeName = "builtin"
+
+// ThemeConfigFile, at the root of a theme, declares the theme it extends.
+const ThemeConfigFile = "theme.yaml"
+
+// ThemeConfig describes a theme. Files missing from a theme are looked up in
+// its parent, which is a path relative to the directory holding the theme,
+// or BuiltinThemeName. Engine names the TemplateEngine of the theme, and is
+// otherwise guessed by NewTemplateEngine.
+type ThemeConfig struct {
+	Name      string               `yaml:"name"`
+	Parent    string               `yaml:"parent"`
+	Engine    string               `yaml:"engine"`
+	Highlight ThemeHighlightConfig `yaml:"highlight"`
+}
+
+// ThemeHighlightConfig names the styles of highlighted code, in light and
+// dark mode. Themes which set neither get DefaultHighlightStyle and
+// DefaultHighlightDarkStyle; those which set only Style have no dark mode.
+type ThemeHighlightConfig struct {
+	Style     string `yaml:"style"`
+	DarkStyle string `yaml:"darkStyle"`
+}
+
+func (c ThemeHighlightConfig) styles() (style, darkStyle string) {
+
+	if c.Style == "" && c.DarkStyle == "" {
+		return DefaultHighlightStyle, DefaultHighlightDarkStyle
+	}
+
+	if c.Style == "" {
+		return DefaultHighlightStyle, c.DarkStyle
+	}
+
+	return c.Style, c.DarkStyle
+}
+
+// maxThemeDepth bounds the parent chain, in case of a loop through links.
+const maxThemeDepth = 16
+
+// LoadTheme reads a theme from a directory, or from a .zip, .tar.gz or .tgz
+// archive, on fs. An empty path loads the built-in theme. The files of the
+// theme are at the root of the returned filesystem, which is read only.
+func LoadTheme(fs afero.Fs, path string) (afero.Fs, error) {
+	return LoadThemeWithOverrides(fs, path, nil)
+}
+
+// LoadThemeWithOverrides reads a theme and its parents, with the override
+// directories laid on top. Each file is taken from the first override that
+// has it, or else from the nearest theme in the chain.
+func LoadThemeWithOverrides(fs afero.Fs, path string, overrides []string) (afero.Fs, error) {
+
+	var layers []afero.Fs
+
+	for _, dir := range overrides {
+
+		layer, err := loadThemeLayer(fs, dir)
+
+		if err != nil {
+			return nil, err
+		}
+
+		layers = append(layers, layer)
+	}
+
+	_, chain, err := loadThemeChain(fs, path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	layers = append(layers, chain...)
+
+	if len(layers) == 1 {
+		return afero.NewReadOnlyFs(layers[0]), nil
+	}
+
+	theme := afero.NewMemMapFs()
+
+	for i := len(layers) - 1; i >= 0; i-- {
+		if err := ExtractTheme(layers[i], theme, themeRoot); err != nil {
+			return nil, err
+		}
+	}
+
+	return afero.NewReadOnlyFs(theme), nil
+}
+
+// ThemeChain returns the paths of a theme and of its parents, nearest first.
+// The built-in theme is left out, as it has no path.
+func ThemeChain(fs afero.Fs, path string) ([]string, error) {
+
+	paths, _, err := loadThemeChain(fs, path)
+
+	return paths, err
+}
+
+func loadThemeChain(fs afero.Fs, path string) (paths []string, layers []afero.Fs, err error) {
+
+	for {
+		if len(layers) == maxThemeDepth {
+			return nil, nil, fmt.Errorf("theme %s: more than %d parents, the chain may loop", path, maxThemeDepth)
+		}
+
+		layer, err := loadThemeLayer(fs, path)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if path != "" {
+			paths = append(paths, path)
+		}
+
+		layers = append(layers, layer)
+
+		config, err := readThemeConfig(layer)
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("theme %s: %s", path, err)
+		}
+
+		switch {
+		case config.Parent == "":
+			return paths, layers, nil
+
+		case config.Parent == BuiltinThemeName:
+			path = ""
+
+		case filepath.IsAbs(config.Parent):
+			path = config.Parent
+
+		default:
+			path = filepath.Join(filepath.Dir(path), config.Parent)
+		}
+	}
+}
+
+func readThemeConfig(theme afero.Fs) (config ThemeConfig, err error) {
+
+	data, err := afero.ReadFile(theme, path.Join(themeRoot, ThemeConfigFile))
+
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+
+	if err != nil {
+		return config, err
+	}
+
+	err = yaml.UnmarshalStrict(data, &config)
+
+	return
+}
+
+// loadThemeLayer reads a single theme, without its parents.
+func loadThemeLayer(fs afero.Fs, path string) (afero.Fs, error) {
+
+	if path == "" || path == BuiltinThemeName {
+		return BuiltinTheme(), nil
+	}
+
+	info, err := fs.Stat(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("theme %s: %s", path, err)
+	}
+
+	if info.IsDir() {
+		return afero.NewBasePathFs(fs, path), nil
+	}
+
+	data, err := afero.ReadFile(fs, path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var theme afero.Fs
+
+	switch {
+	case strings.HasSuffix(path, ".zip"):
+		theme, err = themeFromZip(data)
+
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		theme, err = themeFromTarGz(data)
+
+	default:
+		err = fmt.Errorf("not a directory, .zip, .tar.gz or .tgz archive")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("theme %s: %s", path, err)
+	}
+
+	return stripThemeDir(theme), nil
+}
+
+// ExtractTheme copies every file of a theme to dir on fs, leaving other files
+// in dir alone.
+func ExtractTheme(theme afero.Fs, fs afero.Fs, dir string) error {
+
+	return afero.Walk(theme, themeRoot, func(file string, info os.FileInfo, err error) error {
+
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		data, err := afero.ReadFile(theme, file)
+
+		if err != nil {
+			return err
+		}
+
+		dest := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(file, themeRoot)))
+
+		if err := fs.MkdirAll(filepath.Dir(dest), os.ModePerm); err != nil {
+			return err
+		}
+
+		return afero.WriteFile(fs, dest, data, 0644)
+	})
+}
+
+func themeFromZip(data []byte) (afero.Fs, error) {
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+
+	if err != nil {
+		return nil, err
+	}
+
+	theme := afero.NewMemMapFs()
+
+	for _, f := range archive.File {
+
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		reader, err := f.Open()
+
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := ioutil.ReadAll(reader)
+		reader.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := writeThemeFile(theme, f.Name, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return theme, nil
+}
+
+func themeFromTarGz(data []byte) (afero.Fs, error) {
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+
+	if err != nil {
+		return nil, err
+	}
+
+	archive := tar.NewReader(gz)
+	theme := afero.NewMemMapFs()
+
+	for {
+		header, err := archive.Next()
+
+		if err == io.EOF {
+			return theme, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		data, err := ioutil.ReadAll(archive)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := writeThemeFile(theme, header.Name, data); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// writeThemeFile adds a file to an in-memory theme. Names are cleaned as if
+// rooted, so that archive entries cannot escape the theme.
+func writeThemeFile(theme afero.Fs, name string, data []byte) error {
+
+	name = path.Clean("/" + filepath.ToSlash(name))
+
+	if err := theme.MkdirAll(path.Dir(name), os.ModePerm); err != nil {
+		return err
+	}
+
+	return afero.WriteFile(theme, name, data, 0644)
+}
+
+// stripThemeDir returns the single directory an archive holds, as archives of
+// a theme usually contain its directory rather than its files.
+func stripThemeDir(theme afero.Fs) afero.Fs {
+
+	entries, err := afero.ReadDir(theme, themeRoot)
+
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() || entries[0].Name() == "ace" {
+		return theme
+	}
+
+	return afero.NewBasePathFs(theme, path.Join(themeRoot, entries[0].Name()))
+}
@@ -0,0 +1,200 @@
+package kman
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+var validThemeFiles = map[string]string{
+	"mytheme/ace/master.ace": "= doctype html",
+	"mytheme/js/site.js":     "var site;",
+}
+
+func newValidThemeZip(t *testing.T) []byte {
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	for name, content := range validThemeFiles {
+		w, err := archive.Create(name)
+		require.Nil(t, err)
+
+		_, err = w.Write([]byte(content))
+		require.Nil(t, err)
+	}
+
+	require.Nil(t, archive.Close())
+
+	return buf.Bytes()
+}
+
+func newValidThemeTarGz(t *testing.T) []byte {
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	archive := tar.NewWriter(gz)
+
+	for name, content := range validThemeFiles {
+		require.Nil(t, archive.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+
+		_, err := archive.Write([]byte(content))
+		require.Nil(t, err)
+	}
+
+	require.Nil(t, archive.Close())
+	require.Nil(t, gz.Close())
+
+	return buf.Bytes()
+}
+
+func Test_TheBuiltinThemeShouldHoldTheDefaultTemplates(t *testing.T) {
+
+	theme, err := LoadTheme(afero.NewMemMapFs(), "")
+	require.Nil(t, err)
+
+	for _, file := range []string{"/ace/master.ace", "/ace/index.ace", "/ace/topic.ace", "/ace/glossary.ace", "/js/search.js"} {
+		exists, err := afero.Exists(theme, file)
+		require.Nil(t, err)
+		require.True(t, exists, file)
+	}
+}
+
+func Test_AThemeCanBeLoadedFromADirectoryOrArchive(t *testing.T) {
+
+	fs := newMockFilesystem(t, validThemeFiles)
+	require.Nil(t, afero.WriteFile(fs, "mytheme.zip", newValidThemeZip(t), 0644))
+	require.Nil(t, afero.WriteFile(fs, "mytheme.tar.gz", newValidThemeTarGz(t), 0644))
+
+	for i, path := range []string{"mytheme", "mytheme.zip", "mytheme.tar.gz"} {
+		t.Run(fmt.Sprintf("Cycle %d: %s", i, path), func(t *testing.T) {
+
+			theme, err := LoadTheme(fs, path)
+			require.Nil(t, err)
+
+			data, err := afero.ReadFile(theme, "/js/site.js")
+			require.Nil(t, err)
+			require.Equal(t, "var site;", string(data))
+
+			require.NotNil(t, afero.WriteFile(theme, "/js/other.js", nil, 0644))
+		})
+	}
+}
+
+func Test_AThemeShouldNotLoadFromOtherFiles(t *testing.T) {
+
+	fs := newMockFilesystem(t, map[string]string{
+		"theme.txt": "not a theme",
+	})
+
+	_, err := LoadTheme(fs, "theme.txt")
+	require.NotNil(t, err)
+
+	_, err = LoadTheme(fs, "missing")
+	require.NotNil(t, err)
+}
+
+func Test_AThemeCanBeExtracted(t *testing.T) {
+
+	fs := afero.NewMemMapFs()
+
+	require.Nil(t, ExtractTheme(BuiltinTheme(), fs, "themes/copy"))
+
+	exists, err := afero.Exists(fs, "themes/copy/ace/master.ace")
+	require.Nil(t, err)
+	require.True(t, exists)
+
+	// An extracted theme renders like the built-in one.
+	theme, err := LoadTheme(fs, "themes/copy")
+	require.Nil(t, err)
+
+	renderer := NewRendererAceWithOptions(fs, "", "public", RendererOptions{Theme: theme})
+	require.Nil(t, renderer.Render(newValidDocumentation(t)))
+
+	exists, err = afero.Exists(fs, "public/usage/advanced/index.html")
+	require.Nil(t, err)
+	require.True(t, exists)
+}
+
+func Test_AThemeShouldFallBackToItsParentsAndOverrides(t *testing.T) {
+
+	fs := newMockFilesystem(t, map[string]string{
+		"themes/base/ace/master.ace": "base master",
+		"themes/base/ace/menu.ace":   "base menu",
+		"themes/base/css/site.css":   "base css",
+		"themes/child/theme.yaml":    "name: child\nparent: base\n",
+		"themes/child/ace/menu.ace":  "child menu",
+		"overrides/css/site.css":     "override css",
+	})
+
+	chain, err := ThemeChain(fs, "themes/child")
+	require.Nil(t, err)
+	require.Equal(t, []string{"themes/child", "themes/base"}, chain)
+
+	theme, err := LoadThemeWithOverrides(fs, "themes/child", []string{"overrides"})
+	require.Nil(t, err)
+
+	for file, content := range map[string]string{
+		"/ace/master.ace": "base master",
+		"/ace/menu.ace":   "child menu",
+		"/css/site.css":   "override css",
+	} {
+		data, err := afero.ReadFile(theme, file)
+		require.Nil(t, err)
+		require.Equal(t, content, string(data), file)
+	}
+}
+
+func Test_AThemeCanExtendTheBuiltinTheme(t *testing.T) {
+
+	fs := newMockFilesystem(t, map[string]string{
+		"mytheme/theme.yaml":  "parent: builtin\n",
+		"mytheme/css/app.css": "body {}",
+	})
+
+	theme, err := LoadTheme(fs, "mytheme")
+	require.Nil(t, err)
+
+	for _, file := range []string{"/ace/master.ace", "/css/app.css"} {
+		exists, err := afero.Exists(theme, file)
+		require.Nil(t, err)
+		require.True(t, exists, file)
+	}
+
+	// The theme config is not copied to the site.
+	renderer := NewRendererAceWithOptions(fs, "", "public", RendererOptions{Theme: theme})
+	require.Nil(t, renderer.Render(newValidDocumentation(t)))
+
+	exists, err := afero.Exists(fs, "public/theme.yaml")
+	require.Nil(t, err)
+	require.False(t, exists)
+}
+
+func Test_AThemeShouldNotLoadWithABrokenParentChain(t *testing.T) {
+
+	fs := newMockFilesystem(t, map[string]string{
+		"themes/a/theme.yaml":       "parent: b\n",
+		"themes/b/theme.yaml":       "parent: a\n",
+		"themes/orphan/theme.yaml":  "parent: missing\n",
+		"themes/invalid/theme.yaml": "unknown: setting\n",
+	})
+
+	for _, path := range []string{"themes/a", "themes/orphan", "themes/invalid"} {
+		_, err := LoadTheme(fs, path)
+		require.NotNil(t, err, path)
+	}
+
+	_, err := LoadThemeWithOverrides(fs, "", []string{"missing"})
+	require.NotNil(t, err)
+}
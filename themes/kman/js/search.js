@@ -0,0 +1,205 @@
+// Full text search over window.SearchIndex, written by kman to
+// js/search-index.js. Words are split and stemmed exactly as kman does when
+// building the index (see search.go).
+(function() {
+
+  var titleBoost = 5;
+
+  var stopWords = {};
+  "a an and are as at be by for from in is it of on or that the this to was with".split(" ").forEach(function(w) {
+    stopWords[w] = true;
+  });
+
+  function ascii(word) {
+    return /^[\x00-\x7f]*$/.test(word);
+  }
+
+  function endsWith(word, suffix) {
+    return word.length >= suffix.length && word.slice(-suffix.length) === suffix;
+  }
+
+  function stem(word) {
+    if (word.length <= 3 || !ascii(word)) {
+      return word;
+    }
+
+    if (endsWith(word, "ies") && word.length > 4) {
+      word = word.slice(0, -3) + "y";
+    } else if (endsWith(word, "sses")) {
+      word = word.slice(0, -2);
+    } else if (endsWith(word, "ing") && word.length > 5) {
+      word = word.slice(0, -3);
+    } else if (endsWith(word, "ed") && word.length > 4) {
+      word = word.slice(0, -2);
+    } else if (endsWith(word, "ly") && word.length > 4) {
+      word = word.slice(0, -2);
+    } else if (endsWith(word, "s") && !endsWith(word, "ss") && !endsWith(word, "us") && !endsWith(word, "is")) {
+      word = word.slice(0, -1);
+    }
+
+    var n = word.length;
+    if (n > 3 && word[n - 1] === word[n - 2] && "aeiouslz".indexOf(word[n - 1]) < 0) {
+      word = word.slice(0, -1);
+    }
+
+    n = word.length;
+    if (n > 4 && word[n - 1] === "e") {
+      word = word.slice(0, -1);
+    }
+
+    return word;
+  }
+
+  function words(text) {
+    return text.toLowerCase().split(/[^\p{L}\p{N}]+/u).filter(function(w) {
+      return w !== "" && !stopWords[w];
+    });
+  }
+
+  // matches returns document scores for a query word. The last word of a
+  // query is also matched as a prefix, so results show while typing.
+  function matches(index, word, prefix) {
+    var scores = {};
+    var stemmed = stem(word);
+
+    Object.keys(index.terms).forEach(function(term) {
+      if (term === stemmed || (prefix && term.indexOf(word) === 0)) {
+        index.terms[term].forEach(function(match) {
+          scores[match[0]] = Math.max(scores[match[0]] || 0, match[1]);
+        });
+      }
+    });
+
+    return scores;
+  }
+
+  function search(index, query) {
+    var queryWords = words(query);
+    var totals = null;
+
+    queryWords.forEach(function(word, i) {
+      var scores = matches(index, word, i === queryWords.length - 1);
+      var next = {};
+
+      Object.keys(scores).forEach(function(doc) {
+        if (totals === null || doc in totals) {
+          next[doc] = (totals === null ? 0 : totals[doc]) + scores[doc];
+        }
+      });
+
+      totals = next;
+    });
+
+    return Object.keys(totals || {}).map(function(doc) {
+      return { doc: index.documents[doc], score: totals[doc] };
+    }).sort(function(a, b) {
+      return b.score - a.score || a.doc.title.localeCompare(b.doc.title);
+    });
+  }
+
+  function escape(text) {
+    var div = document.createElement("div");
+    div.textContent = text;
+    return div.innerHTML;
+  }
+
+  // snippet returns the part of the text around the first query word, with
+  // every query word highlighted.
+  function snippet(text, query) {
+    var queryWords = words(query).filter(function(w) { return w.length > 1; });
+    var lower = text.toLowerCase();
+    var start = 0;
+
+    for (var i = 0; i < queryWords.length; i++) {
+      var at = lower.indexOf(stem(queryWords[i]));
+      if (at >= 0) {
+        start = Math.max(0, at - 60);
+        break;
+      }
+    }
+
+    var part = text.slice(start, start + 180);
+    var html = (start > 0 ? "&hellip;" : "") + escape(part) + (start + 180 < text.length ? "&hellip;" : "");
+
+    queryWords.forEach(function(word) {
+      var pattern = new RegExp("(" + stem(word).replace(/[.*+?^${}()|[\]\\]/g, "\\$&") + "[\\p{L}\\p{N}]*)", "giu");
+      html = html.replace(pattern, "<mark>$1</mark>");
+    });
+
+    return html;
+  }
+
+  // link returns the URL of a page of the site from the current page, which
+  // the theme gives as data-root: the base path of the site, or a relative
+  // path to its root when pages link to each other's index.html.
+  function link(url) {
+    var root = document.documentElement.getAttribute("data-root");
+
+    if (root === null) {
+      return url;
+    }
+
+    var i = url.search(/[?#]/);
+    var target = i < 0 ? url : url.slice(0, i);
+    var suffix = i < 0 ? "" : url.slice(i);
+
+    if (root.charAt(0) !== "/" && !/\.[^\/]*$/.test(target)) {
+      target = target.replace(/\/$/, "") + "/index.html";
+    }
+
+    return root + target.replace(/^\//, "") + suffix;
+  }
+
+  function render(results, query, container) {
+    if (query.trim() === "") {
+      container.hidden = true;
+      return;
+    }
+
+    container.hidden = false;
+
+    if (results.length === 0) {
+      container.innerHTML = "<p class=\"search-empty\">No results for <strong>" + escape(query) + "</strong></p>";
+      return;
+    }
+
+    container.innerHTML = "<ul>" + results.slice(0, 20).map(function(result) {
+      return "<li class=\"search-result\">" +
+        "<a href=\"" + escape(link(result.doc.url)) + "\">" + escape(result.doc.title) + "</a>" +
+        (result.doc.type === 1 ? " <span class=\"search-type\">Glossary</span>" : "") +
+        "<p>" + snippet(result.doc.text, query) + "</p>" +
+        "</li>";
+    }).join("") + "</ul>";
+  }
+
+  document.addEventListener("DOMContentLoaded", function() {
+    var input = document.getElementById("kman-search");
+    var container = document.getElementById("kman-search-results");
+
+    if (!input || !container || !window.SearchIndex) {
+      return;
+    }
+
+    var update = function() {
+      render(search(window.SearchIndex, input.value), input.value, container);
+    };
+
+    input.addEventListener("input", update);
+
+    var form = input.form;
+    if (form) {
+      form.addEventListener("submit", function(e) {
+        e.preventDefault();
+        update();
+      });
+    }
+
+    document.addEventListener("keydown", function(e) {
+      if (e.key === "Escape") {
+        container.hidden = true;
+      }
+    });
+  });
+
+  window.KmanSearch = { search: search, stem: stem, words: words };
+})();
@@ -0,0 +1,60 @@
+(function() {
+  "use strict";
+
+  var root = document.documentElement;
+
+  function dark() {
+    var theme = root.getAttribute("data-theme");
+
+    if (theme) {
+      return theme === "dark";
+    }
+
+    return window.matchMedia && window.matchMedia("(prefers-color-scheme: dark)").matches;
+  }
+
+  // The choice is remembered where storage is available, which excludes
+  // some browsers on file:// pages.
+  function toggleTheme() {
+    var theme = dark() ? "light" : "dark";
+
+    root.setAttribute("data-theme", theme);
+
+    try {
+      localStorage.setItem("kman-theme", theme);
+    } catch (e) {}
+  }
+
+  function toggleNav(button, open) {
+    document.body.classList.toggle("nav-open", open);
+    button.setAttribute("aria-expanded", open ? "true" : "false");
+  }
+
+  document.addEventListener("DOMContentLoaded", function() {
+    var themeToggle = document.getElementById("kman-theme-toggle");
+    var navToggle = document.getElementById("kman-nav-toggle");
+    var nav = document.getElementById("kman-site-nav");
+
+    if (themeToggle) {
+      themeToggle.addEventListener("click", toggleTheme);
+    }
+
+    if (navToggle && nav) {
+      navToggle.addEventListener("click", function() {
+        toggleNav(navToggle, !document.body.classList.contains("nav-open"));
+      });
+
+      nav.addEventListener("click", function(e) {
+        if (e.target.tagName === "A") {
+          toggleNav(navToggle, false);
+        }
+      });
+
+      document.addEventListener("keydown", function(e) {
+        if (e.key === "Escape") {
+          toggleNav(navToggle, false);
+        }
+      });
+    }
+  });
+})();
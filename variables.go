@@ -0,0 +1,144 @@
+package kman
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// VersionGit is the version setting which takes the version from the latest
+// git tag.
+const VersionGit = "git"
+
+// codeFenceVariables marks fenced code blocks whose references are replaced,
+// after the language, as in "```sh vars".
+const codeFenceVariables = "vars"
+
+// variableReference is {{ .Version }}, {{ .Vars.name }}, {{ env "NAME" }},
+// or {{`text`}} for text which would otherwise be taken for a reference.
+var variableReference = regexp.MustCompile("\\{\\{\\s*(?:(\\.[\\w.]*)|env\\s+\"([^\"]+)\"|`([^`]*)`)\\s*\\}\\}")
+
+// Variables are the values topics and terms refer to in their content.
+type Variables struct {
+	Version string
+	Vars    map[string]string
+}
+
+// expandVariables replaces the references to variables in the content of
+// items by their values. References to variables which aren't defined are
+// errors.
+func (o AssemblerOptions) expandVariables(items []Item) error {
+
+	if o.Variables == nil {
+		return nil
+	}
+
+	for i, item := range items {
+		if err := o.Variables.expand(&items[i]); err != nil {
+			return fmt.Errorf("%s: %s %q: %s", item.FileName, item.Type.Name(), item.Title, err)
+		}
+	}
+
+	return nil
+}
+
+// expand replaces the references in the content of item, stopping at the
+// first which can't be. Fenced code blocks are left alone, unless marked with
+// codeFenceVariables, as they may show template code.
+func (v Variables) expand(item *Item) error {
+
+	if !strings.Contains(item.Content, "{{") {
+		return nil
+	}
+
+	return replaceContentExcept(item, variableReference, unexpandedCodeBlocks(item.Content), v.value)
+}
+
+// unexpandedCodeBlocks returns the ranges of offsets of the fenced code
+// blocks of content, fences included, which aren't marked with
+// codeFenceVariables.
+func unexpandedCodeBlocks(content string) (blocks [][2]int) {
+
+	fence, expanded, start, offset := "", false, 0, 0
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+
+		marker := codeFenceMarker(line)
+
+		switch {
+		case marker == "":
+
+		case fence == "":
+			info := strings.TrimSpace(strings.TrimLeft(line, " ")[len(marker):])
+			fields := strings.Fields(strings.TrimSuffix(strings.TrimPrefix(info, "{"), "}"))
+
+			fence, expanded, start = marker, false, offset
+
+			for i := 1; i < len(fields); i++ {
+				expanded = expanded || fields[i] == codeFenceVariables
+			}
+
+		case strings.HasPrefix(marker, fence) && strings.TrimSpace(line) == marker:
+			if !expanded {
+				blocks = append(blocks, [2]int{start, offset + len(line)})
+			}
+
+			fence = ""
+		}
+
+		offset += len(line)
+	}
+
+	if fence != "" && !expanded {
+		blocks = append(blocks, [2]int{start, len(content)})
+	}
+
+	return
+}
+
+func (v Variables) value(reference []string) (string, error) {
+
+	name, env, text := reference[1], reference[2], reference[3]
+
+	switch {
+	case name == ".Version":
+		if v.Version == "" {
+			return "", fmt.Errorf("{{ .Version }}: no version set")
+		}
+
+		return v.Version, nil
+
+	case strings.HasPrefix(name, ".Vars."):
+		if value, ok := v.Vars[name[len(".Vars."):]]; ok {
+			return value, nil
+		}
+
+		return "", fmt.Errorf("{{ %s }}: undefined variable %q", name, name[len(".Vars."):])
+
+	case name != "":
+		return "", fmt.Errorf("{{ %s }}: undefined name", name)
+
+	case env != "":
+		if value, ok := os.LookupEnv(env); ok {
+			return value, nil
+		}
+
+		return "", fmt.Errorf("{{ env %q }}: environment variable not set", env)
+	}
+
+	return text, nil
+}
+
+// GitVersion returns the latest tag reachable from the current commit of the
+// git repository holding dir.
+func GitVersion(dir string) (string, error) {
+
+	out, err := git(dir, "describe", "--tags", "--abbrev=0")
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
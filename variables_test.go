@@ -0,0 +1,120 @@
+package kman
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_VariablesShouldBeExpandedInContent(t *testing.T) {
+
+	require.Nil(t, os.Setenv("KMAN_TEST_RELEASE", "stable"))
+	defer os.Unsetenv("KMAN_TEST_RELEASE")
+
+	variables := Variables{Version: "v1.2.0", Vars: map[string]string{"chainID": "519374298533"}}
+
+	for i, c := range []struct {
+		description string
+		variables   Variables
+		content     string
+		expected    string
+		err         string
+	}{
+		{
+			"Version, variable and environment",
+			variables,
+			"go get kman@{{ .Version }} --chain {{.Vars.chainID}} ({{ env \"KMAN_TEST_RELEASE\" }})",
+			"go get kman@v1.2.0 --chain 519374298533 (stable)",
+			"",
+		},
+		{
+			"Escaped",
+			variables,
+			"{{`{{ .Title }}`}} and {{ asset `x` }}",
+			"{{ .Title }} and {{ asset `x` }}",
+			"",
+		},
+		{
+			"Fenced code left alone",
+			variables,
+			"{{ .Version }}\n```ace\nh1 {{ .Title }} {{ .Vars.x }}\n```\n~~~~\n```\n{{ .Version }}\n~~~~\n{{ .Version }}",
+			"v1.2.0\n```ace\nh1 {{ .Title }} {{ .Vars.x }}\n```\n~~~~\n```\n{{ .Version }}\n~~~~\nv1.2.0",
+			"",
+		},
+		{
+			"Fenced code marked to expand",
+			variables,
+			"```sh vars\ngo get kman@{{ .Version }}\n```\n```{sh linenos vars}\n{{ .Vars.chainID }}\n```\n{{ .Version }}",
+			"```sh vars\ngo get kman@v1.2.0\n```\n```{sh linenos vars}\n519374298533\n```\nv1.2.0",
+			"",
+		},
+		{
+			"Unclosed fence",
+			variables,
+			"{{ .Version }}\n```\n{{ .Title }}",
+			"v1.2.0\n```\n{{ .Title }}",
+			"",
+		},
+		{
+			"No version",
+			Variables{},
+			"{{ .Version }}",
+			"",
+			"{{ .Version }}: no version set",
+		},
+		{
+			"Undefined variable",
+			variables,
+			"{{ .Vars.chainId }}",
+			"",
+			"{{ .Vars.chainId }}: undefined variable \"chainId\"",
+		},
+		{
+			"Undefined name",
+			variables,
+			"{{ .Versoin }}",
+			"",
+			"{{ .Versoin }}: undefined name",
+		},
+		{
+			"Environment variable not set",
+			variables,
+			"{{ env \"KMAN_TEST_NOPE\" }}",
+			"",
+			"{{ env \"KMAN_TEST_NOPE\" }}: environment variable not set",
+		},
+	} {
+		t.Run(fmt.Sprintf("Cycle %d: %s", i, c.description), func(t *testing.T) {
+
+			item := Item{Content: c.content}
+			err := c.variables.expand(&item)
+
+			if c.err != "" {
+				require.EqualError(t, err, c.err)
+				return
+			}
+
+			require.Nil(t, err)
+			require.Equal(t, c.expected, item.Content)
+		})
+	}
+}
+
+func Test_AnAssemblerShouldExpandVariablesButNotIncludedCode(t *testing.T) {
+
+	fs := newMockFilesystem(t, map[string]string{
+		"docs/topic.md": "Topic: Usage\nkman {{ .Version }}\n{{< include \"page.ace\" >}}",
+		"docs/page.ace": "h1 {{ .Title }}",
+	})
+
+	items, err := NewMarkdownAssemblerWithOptions(fs, AssemblerOptions{Root: "docs", Variables: &Variables{Version: "v1"}}).Assemble()
+
+	require.Nil(t, err)
+	require.Equal(t, "kman v1\nh1 {{ .Title }}", items[0].Content)
+
+	_, err = NewMarkdownAssemblerWithOptions(fs, AssemblerOptions{Root: "docs", Variables: &Variables{}}).Assemble()
+
+	require.EqualError(t, err, "docs/topic.md: topic \"Usage\": {{ .Version }}: no version set")
+}
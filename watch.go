@@ -0,0 +1,196 @@
+package kman
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// WatchSet is what a build reads: the config file, the theme with its parents
+// and overrides, and the sources of the assemblers. Servers watch it, so as
+// to build again when one of its files changes.
+type WatchSet struct {
+	configPath string
+	config     Config
+	themes     []string
+	includes   map[string]bool
+}
+
+// NewWatchSet returns the files read by builds with config, loaded from the
+// file at configPath, which may be empty.
+func NewWatchSet(fs afero.Fs, configPath string, config Config) WatchSet {
+
+	w := WatchSet{config: config}
+
+	if configPath != "" {
+		w.configPath = filepath.Clean(configPath)
+	}
+
+	// A broken parent chain fails the build, which is reported there. The
+	// theme itself is still watched, so that fixing it triggers a rebuild.
+	w.themes, _ = ThemeChain(fs, config.Theme)
+
+	if len(w.themes) == 0 && config.Theme != "" {
+		w.themes = []string{config.Theme}
+	}
+
+	w.themes = append(w.themes, config.Overrides...)
+
+	return w
+}
+
+// Dirs lists the directories to watch: those of the config file and of the
+// included files, and those below the theme and assembler roots which hold
+// files read by builds.
+func (w WatchSet) Dirs(fs afero.Fs) (map[string]bool, error) {
+
+	dirs := make(map[string]bool)
+
+	if w.configPath != "" {
+		dirs[filepath.Dir(w.configPath)] = true
+	}
+
+	var err error
+
+	for _, root := range w.roots() {
+		if walkErr := w.findDirs(fs, root, dirs); walkErr != nil && !os.IsNotExist(walkErr) {
+			err = walkErr
+		}
+	}
+
+	// Included files may be anywhere, even where no assembler reads.
+	for file := range w.includes {
+		dirs[filepath.Dir(file)] = true
+	}
+
+	return dirs, err
+}
+
+// DirsBelow lists the directories to watch from dir down, such as one created
+// after the watch started.
+func (w WatchSet) DirsBelow(fs afero.Fs, dir string) (map[string]bool, error) {
+
+	dirs := make(map[string]bool)
+
+	if strings.HasPrefix(filepath.Base(dir), ".") || w.excluded(dir) {
+		return dirs, nil
+	}
+
+	return dirs, w.findDirs(fs, dir, dirs)
+}
+
+// WithIncludes returns a copy of the set which also holds the files topics
+// and terms include snippets from, as listed by Documentation.Includes.
+func (w WatchSet) WithIncludes(files []string) WatchSet {
+
+	w.includes = make(map[string]bool)
+
+	for _, file := range files {
+		w.includes[filepath.Clean(file)] = true
+	}
+
+	return w
+}
+
+func (w WatchSet) roots() (roots []string) {
+
+	roots = append(roots, w.themes...)
+
+	for _, a := range w.config.Assemblers {
+		roots = append(roots, a.Options().Root)
+	}
+
+	return
+}
+
+func (w WatchSet) findDirs(fs afero.Fs, root string, dirs map[string]bool) error {
+
+	return afero.Walk(fs, root, func(path string, info os.FileInfo, err error) error {
+
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			return nil
+		}
+
+		if path != root && (strings.HasPrefix(info.Name(), ".") || w.excluded(path)) {
+			return filepath.SkipDir
+		}
+
+		dirs[path] = true
+
+		return nil
+	})
+}
+
+// excluded reports whether no assembler reads from the directory, as it is
+// outside their roots or below a directory they exclude, and it is not part
+// of the theme.
+func (w WatchSet) excluded(dir string) bool {
+
+	if w.inTheme(dir) {
+		return false
+	}
+
+	for _, a := range w.config.Assemblers {
+
+		options := a.Options()
+
+		if rel, err := filepath.Rel(options.Root, dir); err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+
+		excluded := false
+
+		for d := dir; !excluded && d != "." && d != options.Root && d != string(filepath.Separator); d = filepath.Dir(d) {
+			excluded = options.Excludes(d)
+		}
+
+		if !excluded {
+			return false
+		}
+	}
+
+	return true
+}
+
+// inTheme reports whether the path is part of the theme, its parents or the
+// project's overrides.
+func (w WatchSet) inTheme(path string) bool {
+
+	for _, theme := range w.themes {
+		if rel, err := filepath.Rel(theme, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Relevant reports whether a change to the file affects the build. Hidden
+// files and the backups of editors never do.
+func (w WatchSet) Relevant(path string) bool {
+
+	path = filepath.Clean(path)
+	base := filepath.Base(path)
+
+	if strings.HasPrefix(base, ".") || strings.HasSuffix(base, "~") || strings.HasSuffix(base, ".swp") {
+		return false
+	}
+
+	if path == w.configPath || w.includes[path] || w.inTheme(path) {
+		return true
+	}
+
+	for _, a := range w.config.Assemblers {
+		if a.Reads(path) {
+			return true
+		}
+	}
+
+	return false
+}
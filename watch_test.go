@@ -0,0 +1,107 @@
+package kman
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newValidWatchSet(t *testing.T) (WatchSet, map[string]string) {
+
+	files := map[string]string{
+		"kman.yaml":                   "theme: themes/mine",
+		"docs/topics.md":              "Topic: A",
+		"docs/nested/terms.md":        "Term: B",
+		"docs/main.go":                "package main",
+		"public/index.html":           "<html></html>",
+		"vendor/lib/readme.md":        "Topic: Vendored",
+		".git/HEAD":                   "ref: refs/heads/master",
+		"themes/mine/ace/topic.ace":   "= content main",
+		"theme/css/site.css":          "body {}",
+		"other/notes.txt":             "Notes",
+		"docs/.topics.md.swp":         "",
+		"docs/topics.md~":             "",
+		"themes/mine/.hidden/file.js": "",
+	}
+
+	config := DefaultConfig()
+	config.Theme = "themes/mine"
+	config.Overrides = []string{"theme"}
+
+	return NewWatchSet(newMockFilesystem(t, files), "kman.yaml", config), files
+}
+
+func Test_AWatchSetShouldKnowWhichChangesAffectTheBuild(t *testing.T) {
+
+	w, _ := newValidWatchSet(t)
+
+	for path, relevant := range map[string]bool{
+		"kman.yaml":                 true,
+		"./kman.yaml":               true,
+		"docs/topics.md":            true,
+		"docs/nested/terms.md":      true,
+		"docs/main.go":              false,
+		"public/index.md":           false,
+		"vendor/lib/readme.md":      false,
+		"themes/mine/ace/topic.ace": true,
+		"theme/css/site.css":        true,
+		"other/notes.txt":           false,
+		"docs/.topics.md.swp":       false,
+		"docs/topics.md~":           false,
+		"docs/.hidden.md":           false,
+	} {
+		require.Equal(t, relevant, w.Relevant(path), path)
+	}
+}
+
+func Test_AWatchSetShouldListTheDirectoriesToWatch(t *testing.T) {
+
+	w, files := newValidWatchSet(t)
+	fs := newMockFilesystem(t, files)
+
+	dirs, err := w.Dirs(fs)
+	require.Nil(t, err)
+
+	require.Equal(t, map[string]bool{
+		".":               true,
+		"docs":            true,
+		"docs/nested":     true,
+		"other":           true,
+		"theme":           true,
+		"theme/css":       true,
+		"themes":          true,
+		"themes/mine":     true,
+		"themes/mine/ace": true,
+	}, dirs)
+
+	require.Nil(t, fs.MkdirAll("docs/new/deeper", 0755))
+
+	dirs, err = w.DirsBelow(fs, "docs/new")
+	require.Nil(t, err)
+	require.Equal(t, map[string]bool{"docs/new": true, "docs/new/deeper": true}, dirs)
+
+	require.Nil(t, fs.MkdirAll("public/css", 0755))
+
+	dirs, err = w.DirsBelow(fs, "public/css")
+	require.Nil(t, err)
+	require.Empty(t, dirs)
+}
+
+func Test_AWatchSetShouldWatchIncludedFiles(t *testing.T) {
+
+	w, files := newValidWatchSet(t)
+	w = w.WithIncludes([]string{"vendor/lib/snippet.go", "./docs/run.sh"})
+
+	for path, relevant := range map[string]bool{
+		"vendor/lib/snippet.go": true,
+		"docs/run.sh":           true,
+		"vendor/lib/readme.md":  false,
+	} {
+		require.Equal(t, relevant, w.Relevant(path), path)
+	}
+
+	dirs, err := w.Dirs(newMockFilesystem(t, files))
+	require.Nil(t, err)
+	require.True(t, dirs["vendor/lib"])
+	require.False(t, dirs["vendor"])
+}